@@ -0,0 +1,394 @@
+package integration_tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/services/executor/commands"
+	"github.com/jacobmcgowan/simple-scheduler/services/executor/modes"
+	"github.com/jacobmcgowan/simple-scheduler/services/executor/webhooks"
+	"github.com/jacobmcgowan/simple-scheduler/services/scheduler/workers"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/resources"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/worker"
+	"github.com/stretchr/testify/require"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestParseCommand(t *testing.T) {
+	command, err := commands.ParseCommand(map[string]any{
+		"command":    "echo $GREETING",
+		"env":        map[string]any{"GREETING": "hello"},
+		"workingDir": "/tmp",
+		"timeout":    1500.0,
+	}, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/bin/sh", "-c", "echo $GREETING"}, command.Args)
+	require.Equal(t, map[string]string{"GREETING": "hello"}, command.Env)
+	require.Equal(t, "/tmp", command.WorkingDir)
+	require.Equal(t, time.Millisecond*1500, command.Timeout)
+
+	command, err = commands.ParseCommand(map[string]any{
+		"command": []any{"echo", "hello"},
+	}, "/bin/bash")
+	require.NoError(t, err)
+	require.Equal(t, []string{"echo", "hello"}, command.Args)
+
+	invalid := []map[string]any{
+		{},
+		{"command": ""},
+		{"command": []any{}},
+		{"command": []any{"echo", 1.0}},
+		{"command": "echo", "env": map[string]any{"COUNT": 1.0}},
+		{"command": "echo", "workingDir": 1.0},
+		{"command": "echo", "timeout": "1s"},
+		{"command": "echo", "timeout": -1.0},
+	}
+	for _, parameters := range invalid {
+		_, err = commands.ParseCommand(parameters, "")
+		require.Error(t, err, parameters)
+	}
+}
+
+func TestExecutor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+		Parameters: map[string]any{
+			"command": "echo $GREETING; echo oops >&2; exit 3",
+			"env": map[string]any{
+				"GREETING": "hello",
+			},
+		},
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	cancelJob := dtos.Job{
+		Name:                t.Name() + "-cancel-job",
+		Enabled:             true,
+		NextRunAt:           time.Now().Add(time.Second),
+		Interval:            60000,
+		RunExecutionTimeout: 500,
+		Parameters: map[string]any{
+			"command": "sleep 30 & wait",
+		},
+	}
+	cancelJobName, err := dbResources.JobRepo.Add(cancelJob)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Millisecond * 250,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	executors := []*worker.Worker{}
+	for _, name := range []string{jobName, cancelJobName} {
+		executor := &worker.Worker{
+			JobName:           name,
+			MessageBus:        msgBusResources.MessageBus,
+			Handler:           commands.CommandHandler{},
+			HeartbeatDuration: time.Millisecond * 100,
+		}
+		err = executor.Start(&wg)
+		require.NoError(t, err)
+		executors = append(executors, executor)
+	}
+
+	time.Sleep(time.Second * 3)
+
+	for _, executor := range executors {
+		executor.Stop()
+	}
+	mngr.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Failed, runs[0].Status)
+	require.Equal(t, commands.NonZeroExitCode, runs[0].ErrorCode)
+	require.NotNil(t, runs[0].ExitCode)
+	require.Equal(t, 3, *runs[0].ExitCode)
+
+	logs, err := dbResources.RunLogRepo.Browse(dtos.RunLogFilter{
+		RunId: &runs[0].Id,
+	})
+	require.NoError(t, err)
+	messages := map[string]string{}
+	for _, runLog := range logs {
+		messages[runLog.Message] = runLog.Level
+	}
+	require.Equal(t, map[string]string{"hello": "info", "oops": "error"}, messages)
+
+	runs, err = dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &cancelJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Cancelled, runs[0].Status)
+	require.False(t, runs[0].Forced)
+}
+
+func TestParseRequest(t *testing.T) {
+	request, err := webhooks.ParseRequest(map[string]any{
+		"http": map[string]any{
+			"url":                 "https://example.com/export",
+			"headers":             map[string]any{"Authorization": "Bearer token"},
+			"body":                `{"region":{{json .Parameters.region}}}`,
+			"expectedStatusCodes": []any{200.0, 202.0},
+			"timeout":             1500.0,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.MethodPost, request.Method)
+	require.Equal(t, "https://example.com/export", request.Url)
+	require.Equal(t, map[string]string{"Authorization": "Bearer token"}, request.Headers)
+	require.NotNil(t, request.BodyTemplate)
+	require.Equal(t, time.Millisecond*1500, request.Timeout)
+	require.True(t, request.IsExpectedStatus(202))
+	require.False(t, request.IsExpectedStatus(204))
+
+	request, err = webhooks.ParseRequest(map[string]any{
+		"http": map[string]any{
+			"url":    "http://example.com/export",
+			"method": "put",
+			"body":   map[string]any{"region": "eu"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.MethodPut, request.Method)
+	require.JSONEq(t, `{"region":"eu"}`, string(request.Body))
+	require.Equal(t, "application/json", request.Headers["Content-Type"])
+	require.True(t, request.IsExpectedStatus(204))
+	require.False(t, request.IsExpectedStatus(301))
+
+	request, err = webhooks.ParseRequest(map[string]any{
+		"http": map[string]any{
+			"url": "http://example.com/health",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.MethodGet, request.Method)
+
+	invalid := []map[string]any{
+		{"http": "http://example.com"},
+		{"http": map[string]any{}},
+		{"http": map[string]any{"url": "ftp://example.com"}},
+		{"http": map[string]any{"url": "/relative"}},
+		{"http": map[string]any{"url": "http://example.com", "body": "{{.Missing"}},
+		{"http": map[string]any{"url": "http://example.com", "headers": map[string]any{"X-Count": 1.0}}},
+		{"http": map[string]any{"url": "http://example.com", "expectedStatusCodes": []any{"200"}}},
+		{"http": map[string]any{"url": "http://example.com", "expectedStatusCodes": []any{99.0}}},
+		{"http": map[string]any{"url": "http://example.com", "timeout": -1.0}},
+	}
+	for _, parameters := range invalid {
+		_, err = webhooks.ParseRequest(parameters)
+		require.Error(t, err, parameters)
+	}
+
+	require.Equal(t, modes.Http, modes.RunMode(map[string]any{"http": map[string]any{}}))
+	require.Equal(t, modes.Command, modes.RunMode(map[string]any{"command": "ls"}))
+}
+
+func TestWebhookExecutor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/export":
+			body, _ := io.ReadAll(r.Body)
+			received <- string(body)
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("export started for all regions"))
+		case "/slow":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second * 30):
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+		Parameters: map[string]any{
+			"region": "eu",
+			"http": map[string]any{
+				"url":  server.URL + "/export",
+				"body": `{"region":{{json .Parameters.region}}}`,
+			},
+		},
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	failJob := dtos.Job{
+		Name:      t.Name() + "-fail-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+		Parameters: map[string]any{
+			"http": map[string]any{
+				"url": server.URL + "/missing",
+			},
+		},
+	}
+	failJobName, err := dbResources.JobRepo.Add(failJob)
+	require.NoError(t, err)
+
+	cancelJob := dtos.Job{
+		Name:                t.Name() + "-cancel-job",
+		Enabled:             true,
+		NextRunAt:           time.Now().Add(time.Second),
+		Interval:            60000,
+		RunExecutionTimeout: 500,
+		Parameters: map[string]any{
+			"http": map[string]any{
+				"url": server.URL + "/slow",
+			},
+		},
+	}
+	cancelJobName, err := dbResources.JobRepo.Add(cancelJob)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Millisecond * 250,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	handler := modes.ModeHandler{
+		Handlers: map[modes.ExecutorMode]worker.Handler{
+			modes.Http: webhooks.WebhookHandler{
+				MaxResponseBodyLength: 14,
+			},
+		},
+	}
+	executors := []*worker.Worker{}
+	for _, name := range []string{jobName, failJobName, cancelJobName} {
+		executor := &worker.Worker{
+			JobName:           name,
+			MessageBus:        msgBusResources.MessageBus,
+			Handler:           handler,
+			HeartbeatDuration: time.Millisecond * 100,
+		}
+		err = executor.Start(&wg)
+		require.NoError(t, err)
+		executors = append(executors, executor)
+	}
+
+	time.Sleep(time.Second * 3)
+
+	for _, executor := range executors {
+		executor.Stop()
+	}
+	mngr.Stop()
+	wg.Wait()
+
+	require.JSONEq(t, `{"region":"eu"}`, <-received)
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Completed, runs[0].Status)
+	require.EqualValues(t, http.StatusAccepted, runs[0].Result["statusCode"])
+	require.Equal(t, "export started", runs[0].Result["body"])
+	require.Equal(t, true, runs[0].Result["truncated"])
+
+	runs, err = dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &failJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Failed, runs[0].Status)
+	require.Equal(t, webhooks.UnexpectedStatusCode, runs[0].ErrorCode)
+	require.EqualValues(t, http.StatusNotFound, runs[0].Result["statusCode"])
+
+	runs, err = dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &cancelJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Cancelled, runs[0].Status)
+}
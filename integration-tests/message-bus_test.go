@@ -0,0 +1,289 @@
+package integration_tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/services/scheduler/workers"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	messageBus "github.com/jacobmcgowan/simple-scheduler/shared/message-bus"
+	"github.com/jacobmcgowan/simple-scheduler/shared/message-bus/webhookMessageBus"
+	"github.com/jacobmcgowan/simple-scheduler/shared/resources"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/stretchr/testify/require"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+const testWebhookSecret = "test-secret"
+
+// sendCallback sends a signed callback to a webhook message bus and returns
+// the response's status code.
+func sendCallback(addr string, secret string, jobName string, key string, msg any) (int, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "http://"+addr+"/jobs/"+jobName+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set(webhookMessageBus.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhookMessageBus.SignatureHeader, webhookMessageBus.Sign(secret, timestamp, req.URL.EscapedPath(), key, body))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+func postCallback(t *testing.T, addr string, secret string, jobName string, key string, msg any) int {
+	statusCode, err := sendCallback(addr, secret, jobName, key, msg)
+	require.NoError(t, err)
+	return statusCode
+}
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"runId":"6799b53b33fcc6482f29c96f"}`)
+	path := "/jobs/my-job/status"
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := webhookMessageBus.Sign(testWebhookSecret, now.Unix(), path, "status", body)
+
+	require.NoError(t, webhookMessageBus.Verify(testWebhookSecret, timestamp, signature, path, "status", body, now))
+	require.Error(t, webhookMessageBus.Verify("other-secret", timestamp, signature, path, "status", body, now))
+	require.Error(t, webhookMessageBus.Verify(testWebhookSecret, timestamp, signature, path, "status", []byte(`{}`), now))
+	require.Error(t, webhookMessageBus.Verify(testWebhookSecret, "invalid", signature, path, "status", body, now))
+	require.Error(t, webhookMessageBus.Verify(testWebhookSecret, timestamp, signature, path, "status", body, now.Add(time.Minute*10)))
+
+	// A signed message cannot be replayed to another job or as another type of
+	// message
+	require.Error(t, webhookMessageBus.Verify(testWebhookSecret, timestamp, signature, "/jobs/other-job/status", "status", body, now))
+	require.Error(t, webhookMessageBus.Verify(testWebhookSecret, timestamp, signature, path, "heartbeat", body, now))
+}
+
+func TestWebhookMessageBus(t *testing.T) {
+	attempts := atomic.Int32{}
+	pushed := make(chan *http.Request, 1)
+	pushedBody := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		pushed <- r
+		pushedBody <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	msgBus := webhookMessageBus.WebhookMessageBus{
+		ListenAddress: "127.0.0.1:0",
+		Secret:        testWebhookSecret,
+		CallbackUrl:   server.URL + "/jobs/{jobName}/actions",
+		CallbackUrls: map[string]string{
+			"other-job": server.URL + "/other",
+		},
+		RetryDelay: time.Millisecond * 10,
+	}
+	require.NoError(t, msgBus.Connect())
+	defer msgBus.Close()
+
+	exchange := "scheduler.job.my job"
+	err := msgBus.Register(exchange, map[string][]string{
+		exchange + ".action":    {"action"},
+		exchange + ".status":    {"status"},
+		exchange + ".heartbeat": {"heartbeat"},
+	})
+	require.NoError(t, err)
+
+	statuses := make(chan string, 3)
+	wg := sync.WaitGroup{}
+	err = msgBus.Subscribe(&wg, exchange+".status", func(body []byte) (error, bool) {
+		if string(body) == `"invalid"` {
+			return errors.New("invalid status"), false
+		}
+
+		statuses <- string(body)
+		return nil, false
+	})
+	require.NoError(t, err)
+
+	action := []byte(`{"jobName":"my job","action":"run"}`)
+	require.NoError(t, msgBus.Publish(exchange, "action", action))
+	require.EqualValues(t, 2, attempts.Load())
+
+	req := <-pushed
+	require.Equal(t, "/jobs/my%20job/actions", req.URL.EscapedPath())
+	require.Equal(t, "action", req.Header.Get(webhookMessageBus.KeyHeader))
+	err = webhookMessageBus.Verify(
+		testWebhookSecret,
+		req.Header.Get(webhookMessageBus.TimestampHeader),
+		req.Header.Get(webhookMessageBus.SignatureHeader),
+		req.URL.EscapedPath(),
+		req.Header.Get(webhookMessageBus.KeyHeader),
+		<-pushedBody,
+		time.Now(),
+	)
+	require.NoError(t, err)
+
+	addr := msgBus.Addr()
+	require.NotEmpty(t, addr)
+	require.Equal(t, http.StatusNoContent, postCallback(t, addr, testWebhookSecret, "my%20job", "status", "running"))
+	require.Equal(t, `"running"`, <-statuses)
+	require.Equal(t, http.StatusUnauthorized, postCallback(t, addr, "other-secret", "my%20job", "status", "running"))
+	require.Equal(t, http.StatusUnprocessableEntity, postCallback(t, addr, testWebhookSecret, "my%20job", "status", "invalid"))
+	require.Equal(t, http.StatusServiceUnavailable, postCallback(t, addr, testWebhookSecret, "my%20job", "heartbeat", "{}"))
+	require.Equal(t, http.StatusServiceUnavailable, postCallback(t, addr, testWebhookSecret, "unknown", "status", "running"))
+
+	// Keys that are not pushed are delivered to local subscribers, and
+	// messages that cannot be delivered are not dropped silently
+	require.NoError(t, msgBus.Publish(exchange, "status", []byte(`"completed"`)))
+	require.Equal(t, `"completed"`, <-statuses)
+	require.Error(t, msgBus.Publish(exchange, "status", []byte(`"invalid"`)))
+	require.ErrorIs(t, msgBus.Publish(exchange, "heartbeat", []byte("{}")), messageBus.ErrNoSubscribers)
+
+	// A subscriber that fails does not stop the others from receiving
+	err = msgBus.Register(exchange, map[string][]string{
+		exchange + ".audit": {"status"},
+	})
+	require.NoError(t, err)
+	err = msgBus.Subscribe(&wg, exchange+".audit", func(body []byte) (error, bool) {
+		return errors.New("audit unavailable"), true
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, postCallback(t, addr, testWebhookSecret, "my%20job", "status", "cancelled"))
+	require.Equal(t, `"cancelled"`, <-statuses)
+	require.Error(t, msgBus.Publish(exchange, "status", []byte(`"failed"`)))
+	require.Equal(t, `"failed"`, <-statuses)
+
+	msgBus.Unsubscribe(exchange + ".audit")
+	msgBus.Unsubscribe(exchange + ".status")
+	require.Equal(t, http.StatusServiceUnavailable, postCallback(t, addr, testWebhookSecret, "my%20job", "status", "running"))
+
+	// Retries of a push stop after the push timeout
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	unavailableBus := webhookMessageBus.WebhookMessageBus{
+		Secret:      testWebhookSecret,
+		CallbackUrl: unavailable.URL,
+		MaxAttempts: 10,
+		RetryDelay:  time.Second,
+		PushTimeout: time.Millisecond * 100,
+	}
+	require.NoError(t, unavailableBus.Connect())
+	defer unavailableBus.Close()
+
+	start := time.Now()
+	require.Error(t, unavailableBus.Publish(exchange, "action", action))
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestWebhookMessageBusScheduler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	var msgBus *webhookMessageBus.WebhookMessageBus
+
+	// The client responds to run actions by calling back with the run's
+	// statuses, without connecting to a broker
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var actionMsg dtos.JobActionMessage
+		if err := json.NewDecoder(r.Body).Decode(&actionMsg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		go func() {
+			for _, status := range []runStatuses.RunStatus{runStatuses.Running, runStatuses.Completed} {
+				statusCode, err := sendCallback(msgBus.Addr(), testWebhookSecret, actionMsg.JobName, "status", dtos.JobStatusMessage{
+					JobName: actionMsg.JobName,
+					RunId:   actionMsg.RunId,
+					Status:  string(status),
+				})
+				if err != nil || statusCode != http.StatusNoContent {
+					log.Printf("Failed to send status %s for run %s: %d %v", status, actionMsg.RunId, statusCode, err)
+				}
+			}
+		}()
+	}))
+	defer server.Close()
+
+	msgBus = &webhookMessageBus.WebhookMessageBus{
+		ListenAddress: "127.0.0.1:0",
+		Secret:        testWebhookSecret,
+		CallbackUrl:   server.URL + "/jobs/{jobName}/actions",
+	}
+	require.NoError(t, msgBus.Connect())
+	defer msgBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 3)
+
+	mngr.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Completed, runs[0].Status)
+}
@@ -0,0 +1,242 @@
+package integration_tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jacobmcgowan/simple-scheduler/services/api/auth"
+	controllers "github.com/jacobmcgowan/simple-scheduler/services/api/contollers"
+	"github.com/jacobmcgowan/simple-scheduler/services/api/middleware"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/jobActions"
+	"github.com/jacobmcgowan/simple-scheduler/shared/resources"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workerPools"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+const testIssuerKid = "test-key"
+
+// newTestIssuer serves the OpenID configuration and signing key of an issuer,
+// and returns a function that signs access tokens with its private key.
+func newTestIssuer(t *testing.T) (*httptest.Server, func(claims jwt.MapClaims) string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(auth.OpenIdConfig{
+			Issuer:  server.URL,
+			JwksUri: server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(auth.Jwks{
+			Keys: []auth.Jwk{{
+				Kid: testIssuerKid,
+				Kty: "RSA",
+				Alg: jwt.SigningMethodRS256.Alg(),
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	signToken := func(claims jwt.MapClaims) string {
+		claims["iss"] = server.URL
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = testIssuerKid
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	return server, signToken
+}
+
+func TestRunCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	issuer, signToken := newTestIssuer(t)
+	defer issuer.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	controllers.RegisterControllers(
+		router,
+		&auth.AuthCache{Issuer: issuer.URL},
+		time.UTC,
+		dbResources.JobRepo,
+		dbResources.RunRepo,
+		dbResources.RunLogRepo,
+		dbResources.WorkerRepo,
+		msgBusResources.MessageBus,
+	)
+	api := httptest.NewServer(router)
+	defer api.Close()
+
+	job := dtos.Job{
+		Name:       t.Name() + "-job",
+		Enabled:    true,
+		NextRunAt:  time.Now().Add(time.Hour),
+		Interval:   3600000,
+		WorkerPool: "gpu",
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	// Cancel actions are sent to the queue of the pool the run was routed to
+	routingKey := workerPools.RoutingKey(job.WorkerPool, nil)
+	actionQueue := workerPools.ActionQueue(jobName, routingKey)
+	err = msgBusResources.MessageBus.Register(
+		"scheduler.job."+jobName,
+		map[string][]string{
+			actionQueue: {routingKey},
+		},
+	)
+	require.NoError(t, err)
+
+	actions := make(chan dtos.JobActionMessage, 2)
+	wg := sync.WaitGroup{}
+	err = msgBusResources.MessageBus.Subscribe(&wg, actionQueue, func(body []byte) (error, bool) {
+		var action dtos.JobActionMessage
+		if err := json.Unmarshal(body, &action); err != nil {
+			return err, false
+		}
+
+		actions <- action
+		return nil, false
+	})
+	require.NoError(t, err)
+	defer func() {
+		msgBusResources.MessageBus.Unsubscribe(actionQueue)
+		wg.Wait()
+	}()
+
+	addRun := func() string {
+		now := time.Now()
+		runId, err := dbResources.RunRepo.Add(dtos.Run{
+			JobName:     jobName,
+			Status:      runStatuses.Running,
+			CreatedTime: now,
+			StartTime:   now,
+			Heartbeat:   now,
+			Attempt:     1,
+			RoutingKey:  routingKey,
+		})
+		require.NoError(t, err)
+		return runId
+	}
+
+	cancelRun := func(runId string, claims jwt.MapClaims, body string) int {
+		req, err := http.NewRequest(http.MethodPost, api.URL+"/api/runs/"+runId+"/cancel", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+signToken(claims))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	receiveAction := func() dtos.JobActionMessage {
+		select {
+		case action := <-actions:
+			return action
+		case <-time.After(time.Second * 5):
+			require.FailNow(t, "Expected a cancel action to be published")
+			return dtos.JobActionMessage{}
+		}
+	}
+
+	// The user is the token's preferred username
+	runId := addRun()
+	statusCode := cancelRun(runId, jwt.MapClaims{
+		"sub":                "user-1",
+		"preferred_username": "alice",
+		"scope":              "runs:write",
+	}, `{"reason": "wrong input file"}`)
+	require.Equal(t, http.StatusNoContent, statusCode)
+
+	action := receiveAction()
+	require.Equal(t, string(jobActions.Cancel), action.Action)
+	require.Equal(t, runId, action.RunId)
+	require.Equal(t, "wrong input file", action.Reason)
+
+	run, err := dbResources.RunRepo.Read(runId)
+	require.NoError(t, err)
+	require.Equal(t, runStatuses.Cancelling, run.Status)
+	require.Equal(t, "wrong input file", run.Reason)
+	require.Equal(t, "alice", run.CancelledBy)
+	require.False(t, run.CancelRequestedTime.IsZero())
+	require.Len(t, run.Outbox, 1)
+	require.True(t, run.Outbox[0].Sent)
+
+	// Without a preferred username the user is the token's subject, which is
+	// also the default reason
+	runId = addRun()
+	statusCode = cancelRun(runId, jwt.MapClaims{
+		"sub":   "service-1",
+		"scope": "runs:write",
+	}, "")
+	require.Equal(t, http.StatusNoContent, statusCode)
+
+	action = receiveAction()
+	require.Equal(t, runId, action.RunId)
+	require.Equal(t, "cancelled by service-1", action.Reason)
+
+	run, err = dbResources.RunRepo.Read(runId)
+	require.NoError(t, err)
+	require.Equal(t, runStatuses.Cancelling, run.Status)
+	require.Equal(t, "cancelled by service-1", run.Reason)
+	require.Equal(t, "service-1", run.CancelledBy)
+
+	// Runs can only be cancelled with the runs:write scope
+	runId = addRun()
+	statusCode = cancelRun(runId, jwt.MapClaims{
+		"sub":   "user-2",
+		"scope": "runs:read",
+	}, "")
+	require.Equal(t, http.StatusForbidden, statusCode)
+
+	run, err = dbResources.RunRepo.Read(runId)
+	require.NoError(t, err)
+	require.Equal(t, runStatuses.Running, run.Status)
+}
@@ -0,0 +1,31 @@
+package integration_tests
+
+import (
+	"testing"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/validators"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRunParameters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		parameters map[string]any
+		expected   bool
+	}{
+		{nil, true},
+		{map[string]any{"region": "us"}, true},
+		{map[string]any{"region": "us", "command": "rm -rf /"}, false},
+		{map[string]any{"command": []any{"curl", "http://internal"}}, false},
+		{map[string]any{"env": map[string]any{"LD_PRELOAD": "/tmp/x.so"}}, false},
+		{map[string]any{"workingDir": "/"}, false},
+		{map[string]any{"timeout": 0.0}, false},
+		{map[string]any{"http": map[string]any{"url": "http://169.254.169.254/latest/meta-data"}}, false},
+		{map[string]any{"http": map[string]any{"headers": map[string]any{"Authorization": "Bearer x"}}}, false},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, validators.ValidateRunParameters(test.parameters), "%v", test.parameters)
+	}
+}
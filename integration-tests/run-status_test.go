@@ -0,0 +1,42 @@
+package integration_tests
+
+import (
+	"testing"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStatusCanTransition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		from     runStatuses.RunStatus
+		to       runStatuses.RunStatus
+		expected bool
+	}{
+		{runStatuses.Requested, runStatuses.Pending, true},
+		{runStatuses.Requested, runStatuses.Queued, true},
+		{runStatuses.Requested, runStatuses.Skipped, true},
+		{runStatuses.Queued, runStatuses.Cancelled, true},
+		{runStatuses.Pending, runStatuses.Running, true},
+		{runStatuses.Pending, runStatuses.Completed, true},
+		{runStatuses.Running, runStatuses.Pending, true},
+		{runStatuses.Running, runStatuses.Cancelling, true},
+		{runStatuses.Cancelling, runStatuses.Cancelled, true},
+		{runStatuses.Requested, runStatuses.Running, false},
+		{runStatuses.Running, runStatuses.Running, false},
+		{runStatuses.Cancelling, runStatuses.Running, false},
+		{runStatuses.Completed, runStatuses.Running, false},
+		{runStatuses.Failed, runStatuses.Pending, false},
+		{runStatuses.Skipped, runStatuses.Pending, false},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, runStatuses.CanTransition(test.from, test.to), "%s to %s", test.from, test.to)
+	}
+
+	require.ElementsMatch(t, []runStatuses.RunStatus{runStatuses.Pending, runStatuses.Running}, runStatuses.Sources(runStatuses.Cancelling))
+	require.True(t, runStatuses.IsFinished(runStatuses.Skipped))
+	require.False(t, runStatuses.IsFinished(runStatuses.Cancelling))
+}
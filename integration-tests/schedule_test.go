@@ -0,0 +1,119 @@
+package integration_tests
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/schedules"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	t.Parallel()
+
+	after := time.Date(2025, time.June, 4, 10, 30, 15, 0, time.UTC) // Wednesday
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2025, time.June, 4, 10, 31, 0, 0, time.UTC)},
+		{"*/10 * * * * *", time.Date(2025, time.June, 4, 10, 30, 20, 0, time.UTC)},
+		{"15 2 * * MON-FRI", time.Date(2025, time.June, 5, 2, 15, 0, 0, time.UTC)},
+		{"0 9 * * 1#1", time.Date(2025, time.July, 7, 9, 0, 0, 0, time.UTC)},
+		{"0 0 L * *", time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * 0", time.Date(2025, time.June, 8, 12, 0, 0, 0, time.UTC)},
+		{"30 10 4 jun *", time.Date(2026, time.June, 4, 10, 30, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, time.June, 5, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, time.June, 4, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		sched, err := schedules.ParseCron(test.expr)
+		require.NoError(t, err, test.expr)
+		require.Equal(t, test.expected, sched.Next(after), test.expr)
+	}
+}
+
+func TestCronScheduleInvalid(t *testing.T) {
+	t.Parallel()
+
+	exprs := []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * * MON#6",
+		"@fortnightly",
+	}
+
+	for _, expr := range exprs {
+		_, err := schedules.ParseCron(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestCronScheduleTimeZones(t *testing.T) {
+	t.Parallel()
+
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		expr     string
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "standard time",
+			expr:     "15 2 * * *",
+			after:    time.Date(2025, time.January, 10, 12, 0, 0, 0, loc),
+			expected: time.Date(2025, time.January, 11, 7, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "daylight saving time",
+			expr:     "15 2 * * *",
+			after:    time.Date(2025, time.July, 10, 12, 0, 0, 0, loc),
+			expected: time.Date(2025, time.July, 11, 6, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "skipped hour shifts forward",
+			expr:     "30 2 * * *",
+			after:    time.Date(2025, time.March, 9, 0, 0, 0, 0, loc),
+			expected: time.Date(2025, time.March, 9, 7, 30, 0, 0, time.UTC), // 03:30 EDT
+		},
+		{
+			name:     "after skipped hour",
+			expr:     "30 2 * * *",
+			after:    time.Date(2025, time.March, 9, 7, 30, 0, 0, time.UTC),
+			expected: time.Date(2025, time.March, 10, 6, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "repeated hour first occurrence",
+			expr:     "30 1 * * *",
+			after:    time.Date(2025, time.November, 2, 0, 0, 0, 0, loc),
+			expected: time.Date(2025, time.November, 2, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+		},
+		{
+			name:     "repeated hour second occurrence skipped",
+			expr:     "30 1 * * *",
+			after:    time.Date(2025, time.November, 2, 5, 30, 0, 0, time.UTC),
+			expected: time.Date(2025, time.November, 3, 6, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		sched, err := schedules.ParseCron(test.expr)
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.expected, sched.Next(test.after.In(loc)).UTC(), test.name)
+	}
+}
@@ -2,14 +2,25 @@ package integration_tests
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/jacobmcgowan/simple-scheduler/services/scheduler/workers"
+	"github.com/jacobmcgowan/simple-scheduler/shared/backoffStrategies"
+	"github.com/jacobmcgowan/simple-scheduler/shared/concurrencyPolicies"
+	repositoryErrors "github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories/errors"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dispatchModes"
 	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/jobActions"
+	"github.com/jacobmcgowan/simple-scheduler/shared/misfirePolicies"
+	"github.com/jacobmcgowan/simple-scheduler/shared/outbox"
 	"github.com/jacobmcgowan/simple-scheduler/shared/resources"
 	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/timeoutActions"
 	"github.com/stretchr/testify/require"
 
 	"github.com/testcontainers/testcontainers-go"
@@ -54,6 +65,7 @@ func TestRecurringJob(t *testing.T) {
 		ManagerRepo:          dbResources.ManagerRepo,
 		JobRepo:              dbResources.JobRepo,
 		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
 		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
 		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
 		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
@@ -166,6 +178,7 @@ func TestRunCleanup(t *testing.T) {
 		ManagerRepo:          dbResources.ManagerRepo,
 		JobRepo:              dbResources.JobRepo,
 		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
 		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
 		CleanupDuration:      time.Second,
 		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
@@ -258,6 +271,7 @@ func TestRunHeartbeat(t *testing.T) {
 		ManagerRepo:          dbResources.ManagerRepo,
 		JobRepo:              dbResources.JobRepo,
 		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
 		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
 		CleanupDuration:      time.Second,
 		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
@@ -297,6 +311,8 @@ func TestRunHeartbeat(t *testing.T) {
 		run, err := dbResources.RunRepo.Read(runId)
 		require.NoError(t, err)
 		require.Equal(t, runStatuses.Pending, run.Status)
+		require.Len(t, run.Restarts, 1)
+		require.Equal(t, "heartbeat timeout", run.Restarts[0].Reason)
 	}
 
 	mngr.Stop()
@@ -365,6 +381,7 @@ func TestConcurrentManagerWorkers(t *testing.T) {
 		ManagerRepo:          dbResources.ManagerRepo,
 		JobRepo:              dbResources.JobRepo,
 		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
 		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
 		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
 		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
@@ -377,6 +394,7 @@ func TestConcurrentManagerWorkers(t *testing.T) {
 		ManagerRepo:          dbResources.ManagerRepo,
 		JobRepo:              dbResources.JobRepo,
 		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
 		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
 		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
 		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
@@ -433,3 +451,1823 @@ func TestConcurrentManagerWorkers(t *testing.T) {
 		return len(mngrAJobs) == 0 && len(mngrBJobs) == 0 && len(unmngedJobs) == 4
 	}, time.Second*5, time.Millisecond*50, "Expected all jobs to be unassigned after stopping managers")
 }
+
+func TestMaxQueueCount(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:                t.Name() + "-job",
+		Enabled:             true,
+		NextRunAt:           time.Now().Add(time.Second),
+		Interval:            500,
+		MaxQueueCount:       1,
+		AllowConcurrentRuns: true,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 2)
+
+	mngr.Stop()
+	wg.Wait()
+
+	pendingStatus := runStatuses.Pending
+	pendingRuns, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+		Status:  &pendingStatus,
+	})
+	require.NoError(t, err)
+	require.Len(t, pendingRuns, 1)
+
+	skippedStatus := runStatuses.Skipped
+	skippedRuns, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+		Status:  &skippedStatus,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, skippedRuns)
+	for _, run := range skippedRuns {
+		require.NotEmpty(t, run.Reason)
+	}
+}
+
+func TestConcurrencyPolicyQueue(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:              t.Name() + "-job",
+		Enabled:           true,
+		NextRunAt:         time.Now().Add(time.Second),
+		Interval:          500,
+		ConcurrencyPolicy: string(concurrencyPolicies.Queue),
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 2)
+
+	mngr.Stop()
+	wg.Wait()
+
+	pendingStatus := runStatuses.Pending
+	pendingRuns, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+		Status:  &pendingStatus,
+	})
+	require.NoError(t, err)
+	require.Len(t, pendingRuns, 1)
+
+	queuedStatus := runStatuses.Queued
+	queuedRuns, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+		Status:  &queuedStatus,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, queuedRuns)
+	for _, run := range queuedRuns {
+		require.NotEmpty(t, run.Reason)
+	}
+}
+
+func TestMisfirePolicies(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	skipJob := dtos.Job{
+		Name:             t.Name() + "-skip-job",
+		Enabled:          true,
+		NextRunAt:        time.Now().Add(-time.Minute * 10),
+		Interval:         60000,
+		MisfirePolicy:    string(misfirePolicies.Skip),
+		MisfireThreshold: 1000,
+	}
+	skipJobName, err := dbResources.JobRepo.Add(skipJob)
+	require.NoError(t, err)
+
+	fireAllJob := dtos.Job{
+		Name:                t.Name() + "-fire-all-job",
+		Enabled:             true,
+		NextRunAt:           time.Now().Add(-time.Minute * 10),
+		Interval:            60000,
+		AllowConcurrentRuns: true,
+		MisfirePolicy:       string(misfirePolicies.FireAll),
+		MisfireThreshold:    1000,
+	}
+	fireAllJobName, err := dbResources.JobRepo.Add(fireAllJob)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 2)
+
+	mngr.Stop()
+	wg.Wait()
+
+	skipRuns, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &skipJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, skipRuns, 1)
+	require.Equal(t, runStatuses.Skipped, skipRuns[0].Status)
+
+	fireAllRuns, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &fireAllJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, fireAllRuns, 11)
+
+	skipJob, err = dbResources.JobRepo.Read(skipJobName)
+	require.NoError(t, err)
+	require.True(t, skipJob.NextRunAt.After(time.Now()))
+}
+
+func TestRunRetry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+		RetryPolicy: dtos.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     string(backoffStrategies.Exponential),
+			Delay:       100,
+		},
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	client := TestClientWorker{
+		Job:               job,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+	}
+	client.RunStarted = func(runId string) {
+		require.NoError(t, client.FailRun(runId))
+	}
+	err = client.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 3)
+
+	mngr.Stop()
+	client.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+
+	slices.SortFunc(runs, func(a dtos.Run, b dtos.Run) int {
+		return a.Attempt - b.Attempt
+	})
+	for i, run := range runs {
+		require.Equal(t, i+1, run.Attempt)
+		require.Equal(t, runStatuses.Failed, run.Status)
+		if i > 0 {
+			require.Equal(t, runs[0].Id, run.OriginalRunId)
+		}
+	}
+}
+
+func TestManualRun(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	nextRunAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: nextRunAt,
+		Interval:  60000,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	runId, err := dbResources.RunRepo.Add(dtos.Run{
+		JobName:     jobName,
+		Status:      runStatuses.Requested,
+		CreatedTime: time.Now(),
+		Attempt:     1,
+		Manual:      true,
+	})
+	require.NoError(t, err)
+
+	// Manual runs are skipped the same as scheduled runs while another run of
+	// a job without concurrent runs is active
+	otherRunId, err := dbResources.RunRepo.Add(dtos.Run{
+		JobName:     jobName,
+		Status:      runStatuses.Requested,
+		CreatedTime: time.Now(),
+		Attempt:     1,
+		Manual:      true,
+	})
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+		RequestPollDuration:  time.Millisecond * 100,
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	startedRuns := []string{}
+	client := TestClientWorker{
+		Job:               job,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+		RunStarted: func(runId string) {
+			startedRuns = append(startedRuns, runId)
+		},
+	}
+	err = client.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 2)
+
+	mngr.Stop()
+	client.Stop()
+	wg.Wait()
+
+	require.Len(t, startedRuns, 1)
+	if startedRuns[0] == otherRunId {
+		runId, otherRunId = otherRunId, runId
+	}
+	require.Equal(t, []string{runId}, startedRuns)
+
+	run, err := dbResources.RunRepo.Read(runId)
+	require.NoError(t, err)
+	require.Equal(t, runStatuses.Running, run.Status)
+
+	otherRun, err := dbResources.RunRepo.Read(otherRunId)
+	require.NoError(t, err)
+	require.Equal(t, runStatuses.Skipped, otherRun.Status)
+	require.Contains(t, otherRun.Reason, "concurrent runs are not allowed")
+	require.False(t, otherRun.EndTime.IsZero())
+
+	job, err = dbResources.JobRepo.Read(jobName)
+	require.NoError(t, err)
+	require.True(t, nextRunAt.Equal(job.NextRunAt))
+}
+
+func TestRunParameters(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+		Parameters: map[string]any{
+			"region": "eu",
+		},
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 2)
+
+	mngr.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, job.Parameters, runs[0].Parameters)
+}
+
+func TestWorkflow(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	upstreamJob := dtos.Job{
+		Name:      t.Name() + "-upstream-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+	}
+	upstreamJobName, err := dbResources.JobRepo.Add(upstreamJob)
+	require.NoError(t, err)
+
+	downstreamJob := dtos.Job{
+		Name:      t.Name() + "-downstream-job",
+		Enabled:   true,
+		DependsOn: []string{upstreamJobName},
+	}
+	downstreamJobName, err := dbResources.JobRepo.Add(downstreamJob)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+		RequestPollDuration:  time.Millisecond * 100,
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	upstreamClient := TestClientWorker{
+		Job:               upstreamJob,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+	}
+	upstreamClient.RunStarted = func(runId string) {
+		require.NoError(t, upstreamClient.CompleteRun(runId))
+	}
+	err = upstreamClient.Start(&wg)
+	require.NoError(t, err)
+
+	downstreamClient := TestClientWorker{
+		Job:               downstreamJob,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+	}
+	downstreamClient.RunStarted = func(runId string) {
+		require.NoError(t, downstreamClient.CompleteRun(runId))
+	}
+	err = downstreamClient.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 3)
+
+	mngr.Stop()
+	upstreamClient.Stop()
+	downstreamClient.Stop()
+	wg.Wait()
+
+	upstreamRuns, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &upstreamJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, upstreamRuns, 1)
+	require.Equal(t, runStatuses.Completed, upstreamRuns[0].Status)
+
+	downstreamRuns, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &downstreamJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, downstreamRuns, 1)
+	require.Equal(t, runStatuses.Completed, downstreamRuns[0].Status)
+	require.True(t, upstreamRuns[0].Period.Equal(downstreamRuns[0].Period))
+}
+
+func TestJobEdit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	rescheduledJob := dtos.Job{
+		Name:      t.Name() + "-rescheduled-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Hour),
+		Interval:  3600000,
+	}
+	rescheduledJobName, err := dbResources.JobRepo.Add(rescheduledJob)
+	require.NoError(t, err)
+
+	disabledJob := dtos.Job{
+		Name:      t.Name() + "-disabled-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second * 2),
+		Interval:  3600000,
+	}
+	disabledJobName, err := dbResources.JobRepo.Add(disabledJob)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Millisecond * 200,
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+		RequestPollDuration:  time.Millisecond * 100,
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Millisecond * 500)
+
+	nextRunAt := time.Now().Add(time.Second)
+	err = dbResources.JobRepo.Edit(rescheduledJobName, dtos.JobUpdate{
+		NextRunAt: &nextRunAt,
+	})
+	require.NoError(t, err)
+
+	enabled := false
+	err = dbResources.JobRepo.Edit(disabledJobName, dtos.JobUpdate{
+		Enabled: &enabled,
+	})
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 3)
+
+	mngr.Stop()
+	wg.Wait()
+
+	rescheduledRuns, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &rescheduledJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, rescheduledRuns, 1)
+	require.WithinDuration(t, nextRunAt, rescheduledRuns[0].CreatedTime, time.Millisecond)
+
+	disabledRuns, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &disabledJobName,
+	})
+	require.NoError(t, err)
+	require.Empty(t, disabledRuns)
+}
+
+func TestRunStatusTransitions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	client := TestClientWorker{
+		Job:               job,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+	}
+	client.RunStarted = func(runId string) {
+		require.NoError(t, client.CompleteRun(runId))
+		require.NoError(t, client.updateRunStatus(runId, runStatuses.Running))
+		require.NoError(t, client.updateRunStatus(runId, runStatuses.Pending))
+	}
+	err = client.Start(&wg)
+	require.NoError(t, err)
+
+	deadLettersLock := sync.Mutex{}
+	deadLetters := []dtos.DeadLetterMessage{}
+	err = msgBusResources.MessageBus.Subscribe(&wg, "scheduler.job."+jobName+".deadletter", func(body []byte) (error, bool) {
+		var deadLetter dtos.DeadLetterMessage
+		if err := json.Unmarshal(body, &deadLetter); err != nil {
+			return err, false
+		}
+
+		deadLettersLock.Lock()
+		defer deadLettersLock.Unlock()
+		deadLetters = append(deadLetters, deadLetter)
+		return nil, false
+	})
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 3)
+
+	mngr.Stop()
+	client.Stop()
+	msgBusResources.MessageBus.Unsubscribe("scheduler.job." + jobName + ".deadletter")
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Completed, runs[0].Status)
+
+	deadLettersLock.Lock()
+	defer deadLettersLock.Unlock()
+	require.Len(t, deadLetters, 2)
+	for _, deadLetter := range deadLetters {
+		require.Equal(t, "scheduler.job."+jobName+".status", deadLetter.Queue)
+	}
+}
+
+func TestRunRestartLimit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:             t.Name() + "-job",
+		Enabled:          true,
+		NextRunAt:        time.Now().Add(time.Second),
+		Interval:         60000,
+		HeartbeatTimeout: 500,
+		MaxRestarts:      1,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Millisecond * 250,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	deliveriesLock := sync.Mutex{}
+	deliveries := 0
+	client := TestClientWorker{
+		Job:               job,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+		RunStarted: func(runId string) {
+			deliveriesLock.Lock()
+			defer deliveriesLock.Unlock()
+			deliveries++
+		},
+	}
+	err = client.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 4)
+
+	mngr.Stop()
+	client.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Failed, runs[0].Status)
+	require.Len(t, runs[0].Restarts, 1)
+	require.Equal(t, "heartbeat timeout after 1 restarts", runs[0].Reason)
+
+	deliveriesLock.Lock()
+	defer deliveriesLock.Unlock()
+	require.Equal(t, 2, deliveries)
+}
+
+func TestRestartedRunSequence(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:             t.Name() + "-job",
+		Enabled:          true,
+		NextRunAt:        time.Now().Add(time.Hour),
+		Interval:         3600000,
+		HeartbeatTimeout: 500,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	// The run has missed its heartbeat after its client sent a few messages
+	now := time.Now()
+	runId, err := dbResources.RunRepo.Add(dtos.Run{
+		JobName:           jobName,
+		Status:            runStatuses.Running,
+		CreatedTime:       now,
+		StartTime:         now,
+		Heartbeat:         now.Add(-time.Minute),
+		Attempt:           1,
+		Sequence:          5,
+		HeartbeatSequence: 4,
+		MessageIds:        []string{"a", "b"},
+	})
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Millisecond * 250,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	var run dtos.Run
+	require.Eventually(t, func() bool {
+		run, err = dbResources.RunRepo.Read(runId)
+		require.NoError(t, err)
+		return run.Status == runStatuses.Pending
+	}, time.Second*5, time.Millisecond*50, "Expected the run to be restarted")
+
+	mngr.Stop()
+	wg.Wait()
+
+	require.Len(t, run.Restarts, 1)
+	require.Zero(t, run.Sequence)
+	require.Zero(t, run.HeartbeatSequence)
+	require.Empty(t, run.MessageIds)
+
+	// The client numbers the messages of the restart from the start again
+	runningStatus := runStatuses.Running
+	messageId := "a"
+	sequence := int64(1)
+	err = dbResources.RunRepo.Edit(runId, dtos.RunUpdate{
+		Status:    &runningStatus,
+		MessageId: &messageId,
+		Sequence:  &sequence,
+	})
+	require.NoError(t, err)
+
+	run, err = dbResources.RunRepo.Read(runId)
+	require.NoError(t, err)
+	require.Equal(t, runStatuses.Running, run.Status)
+	require.Equal(t, int64(1), run.Sequence)
+	require.Equal(t, []string{"a"}, run.MessageIds)
+}
+
+func TestRunLease(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	// Without a heartbeat timeout, runs are only restarted when their lease
+	// expires
+	job := dtos.Job{
+		Name:         t.Name() + "-job",
+		Enabled:      true,
+		NextRunAt:    time.Now().Add(time.Second),
+		Interval:     60000,
+		MaxRestarts:  1,
+		DispatchMode: string(dispatchModes.Lease),
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	// Reported statuses are handled the same as status messages
+	retryJob := dtos.Job{
+		Name:         t.Name() + "-retryJob",
+		Enabled:      true,
+		NextRunAt:    time.Now().Add(time.Second),
+		Interval:     60000,
+		DispatchMode: string(dispatchModes.Lease),
+		RetryPolicy: dtos.RetryPolicy{
+			MaxAttempts: 2,
+		},
+	}
+	retryJobName, err := dbResources.JobRepo.Add(retryJob)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Millisecond * 250,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+		RequestPollDuration:  time.Millisecond * 100,
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+	defer func() {
+		mngr.Stop()
+		wg.Wait()
+	}()
+
+	newLease := func(workerId string) dtos.RunLease {
+		now := time.Now()
+		return dtos.RunLease{
+			WorkerId:    workerId,
+			LeasedTime:  now,
+			ExpiresTime: now.Add(time.Millisecond * 500),
+		}
+	}
+
+	var run dtos.Run
+	require.Eventually(t, func() bool {
+		run, err = dbResources.RunRepo.Lease(jobName, nil, newLease("worker-a"))
+		return err == nil
+	}, time.Second*5, time.Millisecond*50, "Expected a pending run to lease")
+	require.Equal(t, runStatuses.Running, run.Status)
+	require.NotNil(t, run.Lease)
+	require.Equal(t, "worker-a", run.Lease.WorkerId)
+	require.Empty(t, run.Outbox, "Expected no actions to be published for a leased run")
+
+	// A run is only leased once
+	_, err = dbResources.RunRepo.Lease(jobName, nil, newLease("worker-b"))
+	var notFoundErr *repositoryErrors.NotFoundError
+	require.ErrorAs(t, err, &notFoundErr)
+
+	now := time.Now()
+	otherWorker := "worker-b"
+	err = dbResources.RunRepo.Edit(run.Id, dtos.RunUpdate{
+		Heartbeat:   &now,
+		LeaseHolder: &otherWorker,
+	})
+	var leaseLostErr *repositoryErrors.LeaseLostError
+	require.ErrorAs(t, err, &leaseLostErr)
+
+	require.Eventually(t, func() bool {
+		run, err = dbResources.RunRepo.Read(run.Id)
+		require.NoError(t, err)
+		return run.Status == runStatuses.Pending
+	}, time.Second*5, time.Millisecond*50, "Expected the run to be restarted when its lease expired")
+	require.Nil(t, run.Lease)
+	require.Len(t, run.Restarts, 1)
+	require.Equal(t, "lease expired", run.Restarts[0].Reason)
+
+	run, err = dbResources.RunRepo.Lease(jobName, nil, newLease(otherWorker))
+	require.NoError(t, err)
+	require.Equal(t, otherWorker, run.Lease.WorkerId)
+
+	require.Eventually(t, func() bool {
+		run, err = dbResources.RunRepo.Read(run.Id)
+		require.NoError(t, err)
+		return run.Status == runStatuses.Failed
+	}, time.Second*5, time.Millisecond*50, "Expected the run to fail when its lease expired again")
+	require.Equal(t, "lease expired after 1 restarts", run.Reason)
+
+	var retryRun dtos.Run
+	require.Eventually(t, func() bool {
+		retryRun, err = dbResources.RunRepo.Lease(retryJobName, nil, newLease("worker-c"))
+		return err == nil
+	}, time.Second*5, time.Millisecond*50, "Expected a pending run to lease")
+
+	failedStatus := runStatuses.Failed
+	reported := true
+	leaseHolder := "worker-c"
+	err = dbResources.RunRepo.Edit(retryRun.Id, dtos.RunUpdate{
+		Status:      &failedStatus,
+		EndTime:     &now,
+		Reported:    &reported,
+		LeaseHolder: &leaseHolder,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+			JobName: &retryJobName,
+		})
+		require.NoError(t, err)
+		return len(runs) == 2
+	}, time.Second*5, time.Millisecond*50, "Expected the reported failed run to be retried")
+
+	retryRun, err = dbResources.RunRepo.Read(retryRun.Id)
+	require.NoError(t, err)
+	require.False(t, retryRun.Reported)
+}
+
+func TestCancelGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:                t.Name() + "-job",
+		Enabled:             true,
+		NextRunAt:           time.Now().Add(time.Second),
+		Interval:            60000,
+		RunExecutionTimeout: 500,
+		CancelGracePeriod:   500,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	// Runs forced to cancelled are retried the same as runs cancelled by the
+	// client
+	retryJob := dtos.Job{
+		Name:                t.Name() + "-retryJob",
+		Enabled:             true,
+		NextRunAt:           time.Now().Add(time.Second),
+		Interval:            60000,
+		RunExecutionTimeout: 500,
+		CancelGracePeriod:   500,
+		RetryPolicy: dtos.RetryPolicy{
+			MaxAttempts:    2,
+			RetryOnTimeout: true,
+		},
+	}
+	retryJobName, err := dbResources.JobRepo.Add(retryJob)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Millisecond * 250,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	client := TestClientWorker{
+		Job:               job,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+		IgnoreCancel:      true,
+	}
+	err = client.Start(&wg)
+	require.NoError(t, err)
+
+	retryClient := TestClientWorker{
+		Job:               retryJob,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+		IgnoreCancel:      true,
+	}
+	err = retryClient.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 4)
+
+	mngr.Stop()
+	client.Stop()
+	retryClient.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Cancelled, runs[0].Status)
+	require.True(t, runs[0].TimedOut)
+	require.True(t, runs[0].Forced)
+	require.False(t, runs[0].CancelRequestedTime.IsZero())
+
+	runs, err = dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &retryJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	slices.SortFunc(runs, func(a dtos.Run, b dtos.Run) int {
+		return a.Attempt - b.Attempt
+	})
+	require.Equal(t, runStatuses.Cancelled, runs[0].Status)
+	require.True(t, runs[0].Forced)
+	require.Equal(t, 2, runs[1].Attempt)
+	require.Equal(t, runs[0].Id, runs[1].OriginalRunId)
+}
+
+func TestExecutionTimeoutActions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	failJob := dtos.Job{
+		Name:                   t.Name() + "-failJob",
+		Enabled:                true,
+		NextRunAt:              time.Now().Add(time.Second),
+		Interval:               60000,
+		RunExecutionTimeout:    500,
+		ExecutionTimeoutAction: string(timeoutActions.Fail),
+	}
+	failJobName, err := dbResources.JobRepo.Add(failJob)
+	require.NoError(t, err)
+
+	notifyJob := dtos.Job{
+		Name:                   t.Name() + "-notifyJob",
+		Enabled:                true,
+		NextRunAt:              time.Now().Add(time.Second),
+		Interval:               60000,
+		RunExecutionTimeout:    500,
+		ExecutionTimeoutAction: string(timeoutActions.Notify),
+	}
+	notifyJobName, err := dbResources.JobRepo.Add(notifyJob)
+	require.NoError(t, err)
+
+	// Runs failed by the timeout are retried the same as runs failed by the
+	// client
+	retryJob := dtos.Job{
+		Name:                   t.Name() + "-retryJob",
+		Enabled:                true,
+		NextRunAt:              time.Now().Add(time.Second),
+		Interval:               60000,
+		RunExecutionTimeout:    500,
+		ExecutionTimeoutAction: string(timeoutActions.Fail),
+		RetryPolicy: dtos.RetryPolicy{
+			MaxAttempts: 2,
+		},
+	}
+	retryJobName, err := dbResources.JobRepo.Add(retryJob)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Millisecond * 250,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	failClient := TestClientWorker{
+		Job:               failJob,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+	}
+	err = failClient.Start(&wg)
+	require.NoError(t, err)
+
+	timeoutsLock := sync.Mutex{}
+	timeouts := 0
+	notifyClient := TestClientWorker{
+		Job:               notifyJob,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+		RunTimedOut: func(runId string) {
+			timeoutsLock.Lock()
+			defer timeoutsLock.Unlock()
+			timeouts++
+		},
+	}
+	err = notifyClient.Start(&wg)
+	require.NoError(t, err)
+
+	retryClient := TestClientWorker{
+		Job:               retryJob,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+	}
+	err = retryClient.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 4)
+
+	mngr.Stop()
+	failClient.Stop()
+	notifyClient.Stop()
+	retryClient.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &failJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Failed, runs[0].Status)
+	require.True(t, runs[0].TimedOut)
+
+	runs, err = dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &notifyJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Running, runs[0].Status)
+	require.True(t, runs[0].TimedOut)
+
+	runs, err = dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &retryJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	for _, run := range runs {
+		require.Equal(t, runStatuses.Failed, run.Status)
+		require.True(t, run.TimedOut)
+	}
+
+	timeoutsLock.Lock()
+	defer timeoutsLock.Unlock()
+	require.Equal(t, 1, timeouts)
+}
+
+func TestOutboxDispatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Hour),
+		Interval:  60000,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	// A run whose action was stored but never published, as if the Scheduler
+	// stopped straight after adding it
+	now := time.Now()
+	run := dtos.Run{
+		JobName:     jobName,
+		Status:      runStatuses.Pending,
+		CreatedTime: now,
+		Period:      now,
+		Heartbeat:   now,
+		Attempt:     1,
+		Outbox: []dtos.OutboxMessage{*outbox.NewMessage(dtos.JobActionMessage{
+			Action:  string(jobActions.Run),
+			Attempt: 1,
+		}, now)},
+	}
+	runId, err := dbResources.RunRepo.Add(run)
+	require.NoError(t, err)
+
+	// The action of a run that was cancelled before it was published is no
+	// longer acted on
+	cancelledRun := run
+	cancelledRun.Status = runStatuses.Cancelled
+	cancelledRunId, err := dbResources.RunRepo.Add(cancelledRun)
+	require.NoError(t, err)
+
+	// The action of a run claimed by another dispatcher is published by it
+	claimedRunId, err := dbResources.RunRepo.Add(run)
+	require.NoError(t, err)
+	claimedRun, err := dbResources.RunRepo.Read(claimedRunId)
+	require.NoError(t, err)
+	err = dbResources.RunRepo.ClaimOutboxMessage(claimedRunId, claimedRun.Outbox[0].Id, now.Add(time.Hour))
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+		RequestPollDuration:  time.Millisecond * 250,
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	startedRunsLock := sync.Mutex{}
+	startedRuns := []string{}
+	client := TestClientWorker{
+		Job:               job,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Minute * 1000, // Prevent heartbeat
+		RunStarted: func(runId string) {
+			startedRunsLock.Lock()
+			defer startedRunsLock.Unlock()
+			startedRuns = append(startedRuns, runId)
+		},
+	}
+	err = client.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 3)
+
+	mngr.Stop()
+	client.Stop()
+	wg.Wait()
+
+	run, err = dbResources.RunRepo.Read(runId)
+	require.NoError(t, err)
+	require.Equal(t, runStatuses.Running, run.Status)
+	require.Len(t, run.Outbox, 1)
+	require.True(t, run.Outbox[0].Sent)
+	require.Equal(t, 1, run.Outbox[0].Attempts)
+
+	cancelledRun, err = dbResources.RunRepo.Read(cancelledRunId)
+	require.NoError(t, err)
+	require.False(t, cancelledRun.Outbox[0].Sent)
+	require.True(t, cancelledRun.Outbox[0].Discarded)
+	require.Equal(t, "run is cancelled", cancelledRun.Outbox[0].Error)
+
+	claimedRun, err = dbResources.RunRepo.Read(claimedRunId)
+	require.NoError(t, err)
+	require.False(t, claimedRun.Outbox[0].Sent)
+	require.Zero(t, claimedRun.Outbox[0].Attempts)
+
+	startedRunsLock.Lock()
+	defer startedRunsLock.Unlock()
+	require.Equal(t, []string{runId}, startedRuns)
+}
+
+func TestDuplicateStatusMessages(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	deadLettersLock := sync.Mutex{}
+	deadLetters := 0
+	err = msgBusResources.MessageBus.Subscribe(&wg, "scheduler.job."+jobName+".deadletter", func(body []byte) (error, bool) {
+		deadLettersLock.Lock()
+		defer deadLettersLock.Unlock()
+		deadLetters++
+		return nil, false
+	})
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 2)
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	runId := runs[0].Id
+
+	publish := func(key string, msg any) {
+		body, err := json.Marshal(msg)
+		require.NoError(t, err)
+		require.NoError(t, msgBusResources.MessageBus.Publish("scheduler.job."+jobName, key, body))
+
+		// Status and heartbeat messages are consumed from separate queues
+		time.Sleep(time.Millisecond * 250)
+	}
+	status := func(status runStatuses.RunStatus, messageId string, sequence int64) dtos.JobStatusMessage {
+		return dtos.JobStatusMessage{
+			JobName:   jobName,
+			RunId:     runId,
+			Status:    string(status),
+			MessageId: messageId,
+			Sequence:  sequence,
+		}
+	}
+	heartbeat := func(messageId string, sequence int64) dtos.JobHeartbeatMessage {
+		return dtos.JobHeartbeatMessage{
+			JobName:   jobName,
+			RunId:     runId,
+			MessageId: messageId,
+			Sequence:  sequence,
+		}
+	}
+
+	publish("heartbeat", heartbeat("b", 2)) // Ordered apart from statuses
+	publish("status", status(runStatuses.Running, "a", 1))
+	publish("status", status(runStatuses.Running, "a", 1)) // Redelivered
+	publish("status", status(runStatuses.Completed, "c", 4))
+	publish("heartbeat", heartbeat("d", 1))                // Out of order
+	publish("status", status(runStatuses.Running, "e", 3)) // Out of order
+	publish("heartbeat", heartbeat("", 0))                 // Previous format
+
+	time.Sleep(time.Second * 2)
+
+	mngr.Stop()
+	msgBusResources.MessageBus.Unsubscribe("scheduler.job." + jobName + ".deadletter")
+	wg.Wait()
+
+	run, err := dbResources.RunRepo.Read(runId)
+	require.NoError(t, err)
+	require.Equal(t, runStatuses.Completed, run.Status)
+	require.Equal(t, int64(4), run.Sequence)
+	require.Equal(t, int64(2), run.HeartbeatSequence)
+	require.Equal(t, []string{"b", "a", "c"}, run.MessageIds)
+
+	deadLettersLock.Lock()
+	defer deadLettersLock.Unlock()
+	require.Equal(t, 0, deadLetters)
+}
+
+func TestRunResult(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 2)
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	runId := runs[0].Id
+
+	publish := func(key string, msg any) {
+		body, err := json.Marshal(msg)
+		require.NoError(t, err)
+		require.NoError(t, msgBusResources.MessageBus.Publish("scheduler.job."+jobName, key, body))
+
+		// Status and heartbeat messages are consumed from separate queues
+		time.Sleep(time.Millisecond * 250)
+	}
+
+	startProgress := 0.0
+	publish("status", dtos.JobStatusMessage{
+		JobName:         jobName,
+		RunId:           runId,
+		Status:          string(runStatuses.Running),
+		Progress:        &startProgress,
+		ProgressMessage: "starting export",
+	})
+
+	heartbeatProgress := 40.0
+	publish("heartbeat", dtos.JobHeartbeatMessage{
+		JobName:         jobName,
+		RunId:           runId,
+		Progress:        &heartbeatProgress,
+		ProgressMessage: "exporting",
+	})
+
+	run, err := dbResources.RunRepo.Read(runId)
+	require.NoError(t, err)
+	require.Equal(t, runStatuses.Running, run.Status)
+	require.Equal(t, 40.0, run.Progress)
+	require.Equal(t, "exporting", run.ProgressMessage)
+
+	exitCode := 2
+	publish("status", dtos.JobStatusMessage{
+		JobName:   jobName,
+		RunId:     runId,
+		Status:    string(runStatuses.Failed),
+		Result:    map[string]any{"exported": 1200},
+		Error:     "Export bucket not found",
+		ErrorCode: "BUCKET_NOT_FOUND",
+		ExitCode:  &exitCode,
+	})
+
+	mngr.Stop()
+	wg.Wait()
+
+	run, err = dbResources.RunRepo.Read(runId)
+	require.NoError(t, err)
+	require.Equal(t, runStatuses.Failed, run.Status)
+	require.Equal(t, "Export bucket not found", run.Error)
+	require.Equal(t, "BUCKET_NOT_FOUND", run.ErrorCode)
+	require.NotNil(t, run.ExitCode)
+	require.Equal(t, 2, *run.ExitCode)
+	require.EqualValues(t, 1200, run.Result["exported"])
+	require.Equal(t, 40.0, run.Progress)
+}
+
+func TestRunLogs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+		MaxRunLogLines:       3,
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 2)
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	runId := runs[0].Id
+
+	for i := 1; i <= 5; i++ {
+		body, err := json.Marshal(dtos.JobLogMessage{
+			JobName: jobName,
+			RunId:   runId,
+			Level:   "info",
+			Message: fmt.Sprintf("line %d", i),
+		})
+		require.NoError(t, err)
+		require.NoError(t, msgBusResources.MessageBus.Publish("scheduler.job."+jobName, "log", body))
+	}
+
+	time.Sleep(time.Second)
+
+	mngr.Stop()
+	wg.Wait()
+
+	logs, err := dbResources.RunLogRepo.Browse(dtos.RunLogFilter{
+		RunId: &runId,
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+	require.Equal(t, "line 1", logs[0].Message)
+	require.Equal(t, "line 2", logs[1].Message)
+	require.Equal(t, "warn", logs[2].Level)
+	require.Equal(t, "log truncated at 3 lines", logs[2].Message)
+
+	after := logs[0].Id
+	logs, err = dbResources.RunLogRepo.Browse(dtos.RunLogFilter{
+		RunId: &runId,
+		After: &after,
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	require.Equal(t, "line 2", logs[0].Message)
+}
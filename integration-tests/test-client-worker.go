@@ -20,6 +20,8 @@ type TestClientWorker struct {
 	HeartbeatDuration time.Duration
 	RunStarted        func(runId string)
 	RunCanceled       func(runId string)
+	RunTimedOut       func(runId string)
+	IgnoreCancel      bool
 	quit              chan struct{}
 	isRunningLock     sync.Mutex `default:"sync.Mutex{}"`
 	isRunning         bool
@@ -212,6 +214,10 @@ func (worker *TestClientWorker) actionMessageReceived(body []byte) (error, bool)
 	action := jobActions.JobAction(actionMsg.Action)
 	switch action {
 	case jobActions.Cancel:
+		if worker.IgnoreCancel {
+			return nil, false
+		}
+
 		worker.updateRunStatus(actionMsg.RunId, runStatuses.Cancelled)
 		if worker.RunCanceled != nil {
 			worker.RunCanceled(actionMsg.RunId)
@@ -221,6 +227,10 @@ func (worker *TestClientWorker) actionMessageReceived(body []byte) (error, bool)
 		if worker.RunStarted != nil {
 			worker.RunStarted(actionMsg.RunId)
 		}
+	case jobActions.Timeout:
+		if worker.RunTimedOut != nil {
+			worker.RunTimedOut(actionMsg.RunId)
+		}
 	default:
 		return fmt.Errorf("unsupported action: %s", action), false
 	}
@@ -0,0 +1,65 @@
+package integration_tests
+
+import (
+	"testing"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/validators"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workerPools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPoolRoutingKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pool     string
+		tags     []string
+		expected string
+	}{
+		{"", nil, "action"},
+		{"default", nil, "action"},
+		{"gpu", nil, "action.gpu"},
+		{"", []string{"eu"}, "action.default.eu"},
+		{"gpu", []string{"large", "eu"}, "action.gpu.eu.large"},
+		{"gpu", []string{"eu", "eu"}, "action.gpu.eu"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, workerPools.RoutingKey(test.pool, test.tags), "%s %v", test.pool, test.tags)
+	}
+}
+
+func TestWorkerPoolWorkerRoutingKeys(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"action"}, workerPools.WorkerRoutingKeys("", nil))
+	require.ElementsMatch(
+		t,
+		[]string{"action.gpu", "action.gpu.eu", "action.gpu.large", "action.gpu.eu.large"},
+		workerPools.WorkerRoutingKeys("gpu", []string{"large", "eu"}),
+	)
+
+	// A worker receives the runs of jobs requiring any subset of its tags
+	keys := workerPools.WorkerRoutingKeys("", []string{"eu", "large", "ssd"})
+	require.Len(t, keys, 8)
+	require.Contains(t, keys, workerPools.RoutingKey("", nil))
+	require.Contains(t, keys, workerPools.RoutingKey("default", []string{"ssd", "eu"}))
+	require.NotContains(t, keys, workerPools.RoutingKey("gpu", []string{"eu"}))
+}
+
+func TestWorkerPoolValidate(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, validators.ValidateWorkerPool("", true))
+	require.False(t, validators.ValidateWorkerPool("", false))
+	require.True(t, validators.ValidateWorkerPool("gpu_v2-large", false))
+	require.False(t, validators.ValidateWorkerPool("gpu.large", false))
+	require.False(t, validators.ValidateWorkerPool("gpu*", false))
+	require.False(t, validators.ValidateWorkerPool("gpu#", false))
+
+	require.True(t, validators.ValidateWorkerTags(nil))
+	require.True(t, validators.ValidateWorkerTags([]string{"eu", "large"}))
+	require.False(t, validators.ValidateWorkerTags([]string{"eu", ""}))
+	require.False(t, validators.ValidateWorkerTags([]string{"eu.west"}))
+	require.False(t, validators.ValidateWorkerTags([]string{"a", "b", "c", "d", "e", "f"}))
+}
@@ -0,0 +1,504 @@
+package integration_tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/services/scheduler/workers"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/resources"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/worker"
+	"github.com/stretchr/testify/require"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+func TestWorkerRun(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+		Parameters: map[string]any{
+			"region": "eu",
+		},
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	client := worker.Worker{
+		JobName:    jobName,
+		MessageBus: msgBusResources.MessageBus,
+		Handler: worker.HandlerFunc(func(ctx context.Context, run *worker.Run) error {
+			if err := run.SetProgress(50, "exporting"); err != nil {
+				return err
+			}
+
+			if err := run.Logf("info", "exporting %s", run.Parameters["region"]); err != nil {
+				return err
+			}
+
+			run.SetResult(map[string]any{
+				"region": run.Parameters["region"],
+			})
+			return nil
+		}),
+	}
+	err = client.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 3)
+
+	client.Stop()
+	mngr.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Completed, runs[0].Status)
+	require.Equal(t, "eu", runs[0].Result["region"])
+	require.Equal(t, 100.0, runs[0].Progress)
+
+	logs, err := dbResources.RunLogRepo.Browse(dtos.RunLogFilter{
+		RunId: &runs[0].Id,
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, "exporting eu", logs[0].Message)
+}
+
+func TestWorkerRegistry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	registry := workers.WorkerRegistry{
+		MessageBus: msgBusResources.MessageBus,
+		WorkerRepo: dbResources.WorkerRepo,
+	}
+	err = registry.Start(&wg)
+	require.NoError(t, err)
+
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	runStarted := make(chan struct{})
+	releaseRun := make(chan struct{})
+	client := worker.Worker{
+		JobName:           jobName,
+		MessageBus:        msgBusResources.MessageBus,
+		Hostname:          t.Name() + "-host",
+		Version:           "1.2.0",
+		MaxConcurrentRuns: 2,
+		HeartbeatDuration: time.Millisecond * 250,
+		Handler: worker.HandlerFunc(func(ctx context.Context, run *worker.Run) error {
+			close(runStarted)
+			<-releaseRun
+			return nil
+		}),
+	}
+	err = client.Start(&wg)
+	require.NoError(t, err)
+
+	filter := dtos.WorkerFilter{
+		JobName: &jobName,
+	}
+	select {
+	case <-runStarted:
+	case <-time.After(time.Second * 5):
+		require.Fail(t, "Expected the run to start")
+	}
+
+	var registered []dtos.Worker
+	require.Eventually(t, func() bool {
+		registered, err = dbResources.WorkerRepo.Browse(filter)
+		require.NoError(t, err)
+		return len(registered) == 1 && registered[0].ActiveRuns == 1
+	}, time.Second*5, time.Millisecond*50, "Expected the worker to report its active run")
+	require.Equal(t, client.Id, registered[0].Id)
+	require.Equal(t, t.Name()+"-host", registered[0].Hostname)
+	require.Equal(t, "1.2.0", registered[0].Version)
+	require.Equal(t, 2, registered[0].Capacity)
+	require.Equal(t, []string{jobName}, registered[0].JobNames)
+
+	close(releaseRun)
+	client.Stop()
+
+	require.Eventually(t, func() bool {
+		registered, err = dbResources.WorkerRepo.Browse(filter)
+		require.NoError(t, err)
+		return len(registered) == 0
+	}, time.Second*5, time.Millisecond*50, "Expected the worker to unregister")
+
+	registry.Stop()
+	mngr.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Completed, runs[0].Status)
+	require.Equal(t, client.Id, runs[0].WorkerId)
+}
+
+func TestWorkerPool(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:       t.Name() + "-job",
+		Enabled:    true,
+		NextRunAt:  time.Now().Add(time.Second),
+		Interval:   60000,
+		WorkerPool: "gpu",
+		WorkerTags: []string{"eu"},
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	// Only the worker in the job's pool with all of its tags performs the run
+	newClient := func(pool string, tags []string) *worker.Worker {
+		return &worker.Worker{
+			JobName:    jobName,
+			MessageBus: msgBusResources.MessageBus,
+			Pool:       pool,
+			Tags:       tags,
+			Handler: worker.HandlerFunc(func(ctx context.Context, run *worker.Run) error {
+				return nil
+			}),
+		}
+	}
+	clients := []*worker.Worker{
+		newClient("", nil),
+		newClient("gpu", nil),
+		newClient("cpu", []string{"eu"}),
+		newClient("gpu", []string{"large", "eu"}),
+	}
+	for _, client := range clients {
+		err = client.Start(&wg)
+		require.NoError(t, err)
+	}
+
+	time.Sleep(time.Second * 3)
+
+	for _, client := range clients {
+		client.Stop()
+	}
+	mngr.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Completed, runs[0].Status)
+	require.Equal(t, "action.gpu.eu", runs[0].RoutingKey)
+	require.Equal(t, clients[3].Id, runs[0].WorkerId)
+}
+
+func TestWorkerCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:                t.Name() + "-job",
+		Enabled:             true,
+		NextRunAt:           time.Now().Add(time.Second),
+		Interval:            60000,
+		RunExecutionTimeout: 500,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Millisecond * 250,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	var cause error
+	client := worker.Worker{
+		JobName:           jobName,
+		MessageBus:        msgBusResources.MessageBus,
+		HeartbeatDuration: time.Millisecond * 100,
+		Handler: worker.HandlerFunc(func(ctx context.Context, run *worker.Run) error {
+			<-ctx.Done()
+			cause = context.Cause(ctx)
+			return ctx.Err()
+		}),
+	}
+	err = client.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 3)
+
+	client.Stop()
+	mngr.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Cancelled, runs[0].Status)
+	require.True(t, runs[0].TimedOut)
+	require.False(t, runs[0].Forced)
+	require.ErrorIs(t, cause, worker.ErrCancelled)
+}
+
+func TestWorkerFailures(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cRes := initContainers(t, ctx)
+	defer testcontainers.TerminateContainer(cRes.DbContainer)
+	defer testcontainers.TerminateContainer(cRes.MessageBusContainer)
+
+	dbResources, err := resources.RegisterRepos(cRes.DbEnv)
+	require.NoError(t, err)
+
+	err = dbResources.Context.Connect(ctx)
+	require.NoError(t, err)
+	defer dbResources.Context.Disconnect()
+
+	msgBusResources, err := resources.RegisterMessageBus(cRes.MessageBusEnv)
+	require.NoError(t, err)
+
+	err = msgBusResources.MessageBus.Connect()
+	require.NoError(t, err)
+	defer msgBusResources.MessageBus.Close()
+
+	job := dtos.Job{
+		Name:      t.Name() + "-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+	}
+	jobName, err := dbResources.JobRepo.Add(job)
+	require.NoError(t, err)
+
+	failJob := dtos.Job{
+		Name:      t.Name() + "-fail-job",
+		Enabled:   true,
+		NextRunAt: time.Now().Add(time.Second),
+		Interval:  60000,
+	}
+	failJobName, err := dbResources.JobRepo.Add(failJob)
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	mngr := workers.ManagerWorker{
+		Hostname:             t.Name() + "-manager",
+		MaxJobs:              0,
+		MessageBus:           msgBusResources.MessageBus,
+		ManagerRepo:          dbResources.ManagerRepo,
+		JobRepo:              dbResources.JobRepo,
+		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
+		CacheRefreshDuration: time.Minute * 1000, // Prevent cache refresh
+		CleanupDuration:      time.Minute * 1000, // Prevent cleanup,
+		HeartbeatDuration:    time.Minute * 1000, // Prevent heartbeat
+	}
+	err = mngr.Start(&wg)
+	require.NoError(t, err)
+
+	client := worker.Worker{
+		JobName:    jobName,
+		MessageBus: msgBusResources.MessageBus,
+		Handler: worker.HandlerFunc(func(ctx context.Context, run *worker.Run) error {
+			panic("boom")
+		}),
+	}
+	err = client.Start(&wg)
+	require.NoError(t, err)
+
+	exitCode := 2
+	failClient := worker.Worker{
+		JobName:    failJobName,
+		MessageBus: msgBusResources.MessageBus,
+		Handler: worker.HandlerFunc(func(ctx context.Context, run *worker.Run) error {
+			return &worker.RunError{
+				Err:      errors.New("export bucket not found"),
+				Code:     "BUCKET_NOT_FOUND",
+				ExitCode: &exitCode,
+			}
+		}),
+	}
+	err = failClient.Start(&wg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 3)
+
+	client.Stop()
+	failClient.Stop()
+	mngr.Stop()
+	wg.Wait()
+
+	runs, err := dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &jobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Failed, runs[0].Status)
+	require.Equal(t, "run panicked: boom", runs[0].Error)
+
+	runs, err = dbResources.RunRepo.Browse(dtos.RunFilter{
+		JobName: &failJobName,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, runStatuses.Failed, runs[0].Status)
+	require.Equal(t, "export bucket not found", runs[0].Error)
+	require.Equal(t, "BUCKET_NOT_FOUND", runs[0].ErrorCode)
+	require.NotNil(t, runs[0].ExitCode)
+	require.Equal(t, 2, *runs[0].ExitCode)
+}
@@ -0,0 +1,59 @@
+package integration_tests
+
+import (
+	"testing"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/nodeStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workflows"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowValidateDependencies(t *testing.T) {
+	t.Parallel()
+
+	jobs := []dtos.Job{
+		{Name: "extract"},
+		{Name: "transform", DependsOn: []string{"extract"}},
+		{Name: "load", DependsOn: []string{"transform"}},
+	}
+
+	require.NoError(t, workflows.ValidateDependencies("report", []string{"load"}, jobs))
+	require.NoError(t, workflows.ValidateDependencies("load", []string{"extract", "transform"}, jobs))
+	require.Error(t, workflows.ValidateDependencies("load", []string{"load"}, jobs))
+	require.Error(t, workflows.ValidateDependencies("load", []string{"missing"}, jobs))
+	require.Error(t, workflows.ValidateDependencies("extract", []string{"load"}, jobs))
+}
+
+func TestWorkflowNodes(t *testing.T) {
+	t.Parallel()
+
+	jobs := []dtos.Job{
+		{Name: "load", DependsOn: []string{"transform"}},
+		{Name: "unrelated"},
+		{Name: "transform", DependsOn: []string{"extract"}},
+		{Name: "extract"},
+		{Name: "audit", DependsOn: []string{"extract"}},
+	}
+
+	component := workflows.Component("load", jobs)
+	names := []string{}
+	for _, job := range component {
+		names = append(names, job.Name)
+	}
+	require.Equal(t, []string{"extract", "transform", "load", "audit"}, names)
+
+	runs := []dtos.Run{
+		{Id: "1", JobName: "extract", Status: runStatuses.Completed, Attempt: 1},
+		{Id: "2", JobName: "transform", Status: runStatuses.Failed, Attempt: 1},
+		{Id: "3", JobName: "transform", Status: runStatuses.Failed, Attempt: 2},
+	}
+	nodes := workflows.Nodes(component, runs)
+	require.Len(t, nodes, 4)
+	require.Equal(t, string(runStatuses.Completed), nodes[0].Status)
+	require.Equal(t, "3", nodes[1].RunId)
+	require.Equal(t, string(runStatuses.Failed), nodes[1].Status)
+	require.Equal(t, string(nodeStatuses.Blocked), nodes[2].Status)
+	require.Equal(t, string(nodeStatuses.Waiting), nodes[3].Status)
+}
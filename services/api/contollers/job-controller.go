@@ -1,16 +1,21 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	responseHelpers "github.com/jacobmcgowan/simple-scheduler/services/api/response-helpers"
 	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
 	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/schedules"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workflows"
 )
 
 type JobController struct {
-	jobRepo repositories.JobRepository
+	jobRepo         repositories.JobRepository
+	defaultTimeZone *time.Location
 }
 
 func (cont JobController) Browse(ctx *gin.Context) {
@@ -30,6 +35,34 @@ func (cont JobController) Read(ctx *gin.Context, name string) {
 }
 
 func (cont JobController) Edit(ctx *gin.Context, name string, jobUpdate dtos.JobUpdate) {
+	if jobUpdate.DependsOn != nil && !cont.validateDependencies(ctx, name, *jobUpdate.DependsOn) {
+		return
+	}
+
+	if (jobUpdate.Schedule != nil || jobUpdate.TimeZone != nil) && jobUpdate.NextRunAt == nil {
+		job, err := cont.jobRepo.Read(name)
+		if err != nil {
+			responseHelpers.RespondWithError(ctx, err)
+			return
+		}
+
+		if jobUpdate.Schedule != nil {
+			job.Schedule = *jobUpdate.Schedule
+		}
+		if jobUpdate.TimeZone != nil {
+			job.TimeZone = *jobUpdate.TimeZone
+		}
+
+		if job.Schedule != "" {
+			nextRunAt, ok := cont.nextRunAt(ctx, job.Schedule, job.TimeZone)
+			if !ok {
+				return
+			}
+
+			jobUpdate.NextRunAt = &nextRunAt
+		}
+	}
+
 	if err := cont.jobRepo.Edit(name, jobUpdate); err == nil {
 		ctx.Status(http.StatusNoContent)
 	} else {
@@ -38,6 +71,19 @@ func (cont JobController) Edit(ctx *gin.Context, name string, jobUpdate dtos.Job
 }
 
 func (cont JobController) Add(ctx *gin.Context, job dtos.Job) {
+	if len(job.DependsOn) > 0 && !cont.validateDependencies(ctx, job.Name, job.DependsOn) {
+		return
+	}
+
+	if job.Schedule != "" && job.NextRunAt.IsZero() {
+		nextRunAt, ok := cont.nextRunAt(ctx, job.Schedule, job.TimeZone)
+		if !ok {
+			return
+		}
+
+		job.NextRunAt = nextRunAt
+	}
+
 	if name, err := cont.jobRepo.Add(job); err == nil {
 		ctx.JSON(http.StatusCreated, gin.H{
 			"name": name,
@@ -48,9 +94,53 @@ func (cont JobController) Add(ctx *gin.Context, job dtos.Job) {
 }
 
 func (cont JobController) Delete(ctx *gin.Context, name string) {
+	jobs, err := cont.jobRepo.Browse()
+	if err != nil {
+		responseHelpers.RespondWithError(ctx, err)
+		return
+	}
+
+	if dependents := workflows.Dependents(name, jobs); len(dependents) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Job %s depends on this job", dependents[0].Name),
+		})
+		return
+	}
+
 	if err := cont.jobRepo.Delete(name); err == nil {
 		ctx.Status(http.StatusNoContent)
 	} else {
 		responseHelpers.RespondWithError(ctx, err)
 	}
 }
+
+func (cont JobController) validateDependencies(ctx *gin.Context, name string, dependsOn []string) bool {
+	jobs, err := cont.jobRepo.Browse()
+	if err != nil {
+		responseHelpers.RespondWithError(ctx, err)
+		return false
+	}
+
+	if err := workflows.ValidateDependencies(name, dependsOn, jobs); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"dependsOn": err.Error(),
+		})
+		return false
+	}
+
+	return true
+}
+
+// nextRunAt returns the next time a schedule fires. Schedules that are valid
+// but never fire, such as on February 30th, are rejected.
+func (cont JobController) nextRunAt(ctx *gin.Context, schedule string, timeZone string) (time.Time, bool) {
+	nextRunAt, err := schedules.NextCronTime(schedule, timeZone, cont.defaultTimeZone, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"schedule": err.Error(),
+		})
+		return time.Time{}, false
+	}
+
+	return nextRunAt, true
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	responseHelpers "github.com/jacobmcgowan/simple-scheduler/services/api/response-helpers"
+	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
+	repositoryErrors "github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories/errors"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dispatchModes"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workerPools"
+)
+
+const leasePollDuration = time.Second
+
+// The longest a lease request may wait for a pending run, which should be
+// shorter than the timeouts of proxies between workers and the API.
+const maxLeaseWait = time.Minute
+
+// LeaseController lets workers that cannot consume from the message bus claim
+// pending runs over HTTP. A leased run is running until the worker reports its
+// status or stops renewing the lease, after which the Scheduler restarts it.
+type LeaseController struct {
+	runRepo repositories.RunRepository
+	jobRepo repositories.JobRepository
+}
+
+// Lease claims the oldest pending run of a job routed to the worker's pool and
+// tags, waiting up to the requested time for one. Responds with no content if
+// there is no pending run by then.
+func (cont LeaseController) Lease(ctx *gin.Context, jobName string, leaseRequest dtos.RunLeaseRequest) {
+	job, err := cont.jobRepo.Read(jobName)
+	if err != nil {
+		responseHelpers.RespondWithError(ctx, err)
+		return
+	}
+
+	// The actions of runs of other jobs are published, so leasing them would
+	// run them twice
+	if dispatchModes.DispatchMode(job.DispatchMode) != dispatchModes.Lease {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Job runs are not leased, its dispatch mode is not lease",
+		})
+		return
+	}
+
+	routingKeys := workerPools.WorkerRoutingKeys(leaseRequest.Pool, leaseRequest.Tags)
+
+	waitUntil := time.Now().Add(time.Duration(leaseRequest.Wait) * time.Millisecond)
+	for {
+		now := time.Now()
+		lease := dtos.RunLease{
+			WorkerId:    leaseRequest.WorkerId,
+			LeasedTime:  now,
+			ExpiresTime: now.Add(time.Duration(leaseRequest.LeaseDuration) * time.Millisecond),
+		}
+
+		run, err := cont.runRepo.Lease(jobName, routingKeys, lease)
+		if err == nil {
+			ctx.JSON(http.StatusOK, run)
+			return
+		}
+
+		var notFoundErr *repositoryErrors.NotFoundError
+		if !errors.As(err, &notFoundErr) {
+			responseHelpers.RespondWithError(ctx, err)
+			return
+		}
+
+		if !now.Before(waitUntil) {
+			ctx.Status(http.StatusNoContent)
+			return
+		}
+
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-time.After(min(leasePollDuration, time.Until(waitUntil))):
+		}
+	}
+}
+
+// Renew extends the lease of a run, which also acts as its heartbeat. The run
+// is returned so that the worker can stop if it is being cancelled.
+func (cont LeaseController) Renew(ctx *gin.Context, id string, renewal dtos.RunLeaseRenewal) {
+	run, err := cont.runRepo.Read(id)
+	if err != nil {
+		responseHelpers.RespondWithError(ctx, err)
+		return
+	}
+
+	if run.Lease == nil || run.Lease.WorkerId != renewal.WorkerId {
+		responseHelpers.RespondWithError(ctx, &repositoryErrors.LeaseLostError{
+			Id:       id,
+			WorkerId: renewal.WorkerId,
+		})
+		return
+	}
+
+	now := time.Now()
+	lease := *run.Lease
+	lease.ExpiresTime = now.Add(time.Duration(renewal.LeaseDuration) * time.Millisecond)
+	runUpdate := dtos.RunUpdate{
+		Heartbeat:   &now,
+		Progress:    renewal.Progress,
+		Lease:       &lease,
+		LeaseHolder: &renewal.WorkerId,
+	}
+	if renewal.ProgressMessage != "" {
+		runUpdate.ProgressMessage = &renewal.ProgressMessage
+	}
+
+	if err := cont.runRepo.Edit(id, runUpdate); err != nil {
+		responseHelpers.RespondWithError(ctx, err)
+		return
+	}
+
+	if run, err := cont.runRepo.Read(id); err == nil {
+		ctx.JSON(http.StatusOK, run)
+	} else {
+		responseHelpers.RespondWithError(ctx, err)
+	}
+}
+
+// ReportStatus stores the outcome of a leased run. The Scheduler managing its
+// job then retries the run or releases the runs waiting on it, the same as for
+// a status message from a worker consuming from the message bus.
+func (cont LeaseController) ReportStatus(ctx *gin.Context, id string, report dtos.RunStatusReport) {
+	status := runStatuses.RunStatus(report.Status)
+	now := time.Now()
+	reported := true
+	runUpdate := dtos.RunUpdate{
+		Status:      &status,
+		EndTime:     &now,
+		ExitCode:    report.ExitCode,
+		Progress:    report.Progress,
+		Reported:    &reported,
+		LeaseHolder: &report.WorkerId,
+	}
+	if report.MessageId != "" {
+		runUpdate.MessageId = &report.MessageId
+	}
+	if report.Result != nil {
+		runUpdate.Result = &report.Result
+	}
+	if report.Error != "" {
+		runUpdate.Error = &report.Error
+	}
+	if report.ErrorCode != "" {
+		runUpdate.ErrorCode = &report.ErrorCode
+	}
+	if report.ProgressMessage != "" {
+		runUpdate.ProgressMessage = &report.ProgressMessage
+	}
+
+	if err := cont.runRepo.Edit(id, runUpdate); err != nil {
+		// The message ID lets workers retry a report that was already stored
+		var staleErr *repositoryErrors.StaleMessageError
+		if errors.As(err, &staleErr) {
+			ctx.Status(http.StatusNoContent)
+			return
+		}
+
+		responseHelpers.RespondWithError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -1,18 +1,37 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jacobmcgowan/simple-scheduler/services/api/auth"
 	"github.com/jacobmcgowan/simple-scheduler/services/api/middleware"
 	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
 	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/executorParameters"
+	messageBus "github.com/jacobmcgowan/simple-scheduler/shared/message-bus"
+	"github.com/jacobmcgowan/simple-scheduler/shared/outbox"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
 	"github.com/jacobmcgowan/simple-scheduler/shared/validators"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workerPools"
 )
 
-func RegisterControllers(router *gin.Engine, authCache *auth.AuthCache, jobRepo repositories.JobRepository, runRepo repositories.RunRepository) {
+const workerPoolError = "Invalid worker pool, must only contain letters, digits, hyphens and underscores"
+
+var workerTagsError = fmt.Sprintf("Invalid worker tags, must be at most %d tags that only contain letters, digits, hyphens and underscores", workerPools.MaxTags)
+
+func RegisterControllers(router *gin.Engine, authCache *auth.AuthCache, defaultTimeZone *time.Location, jobRepo repositories.JobRepository, runRepo repositories.RunRepository, runLogRepo repositories.RunLogRepository, workerRepo repositories.WorkerRepository, msgBus messageBus.MessageBus) {
 	api := router.Group("/api")
+	dispatcher := &outbox.Dispatcher{
+		MessageBus: msgBus,
+		RunRepo:    runRepo,
+	}
 
 	status := api.Group("/status")
 	status.GET("", func(ctx *gin.Context) {
@@ -43,8 +62,72 @@ func RegisterControllers(router *gin.Engine, authCache *auth.AuthCache, jobRepo
 			return
 		}
 
+		if !validators.ValidateSchedule(job.Schedule, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"schedule": "Invalid cron schedule",
+			})
+			return
+		}
+
+		if !validators.ValidateTimeZone(job.TimeZone, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"timeZone": "Invalid IANA time zone",
+			})
+			return
+		}
+
+		if !validators.ValidateConcurrencyPolicy(job.ConcurrencyPolicy, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"concurrencyPolicy": "Invalid concurrency policy",
+			})
+			return
+		}
+
+		if !validators.ValidateMisfirePolicy(job.MisfirePolicy, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"misfirePolicy": "Invalid misfire policy",
+			})
+			return
+		}
+
+		if !validators.ValidateTimeoutAction(job.ExecutionTimeoutAction, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"executionTimeoutAction": "Invalid execution timeout action",
+			})
+			return
+		}
+
+		if !validators.ValidateBackoffStrategy(job.RetryPolicy.Backoff, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"retryPolicy": "Invalid backoff strategy",
+			})
+			return
+		}
+
+		if !validators.ValidateWorkerPool(job.WorkerPool, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"workerPool": workerPoolError,
+			})
+			return
+		}
+
+		if !validators.ValidateWorkerTags(job.WorkerTags) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"workerTags": workerTagsError,
+			})
+			return
+		}
+
+		if !validators.ValidateDispatchMode(job.DispatchMode, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"dispatchMode": "Invalid dispatch mode",
+			})
+			return
+		}
+
 		cont := JobController{
-			jobRepo: jobRepo,
+			jobRepo:         jobRepo,
+			defaultTimeZone: defaultTimeZone,
 		}
 		cont.Add(ctx, job)
 	})
@@ -59,8 +142,72 @@ func RegisterControllers(router *gin.Engine, authCache *auth.AuthCache, jobRepo
 			return
 		}
 
+		if jobUpdate.Schedule != nil && !validators.ValidateSchedule(*jobUpdate.Schedule, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"schedule": "Invalid cron schedule",
+			})
+			return
+		}
+
+		if jobUpdate.TimeZone != nil && !validators.ValidateTimeZone(*jobUpdate.TimeZone, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"timeZone": "Invalid IANA time zone",
+			})
+			return
+		}
+
+		if jobUpdate.ConcurrencyPolicy != nil && !validators.ValidateConcurrencyPolicy(*jobUpdate.ConcurrencyPolicy, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"concurrencyPolicy": "Invalid concurrency policy",
+			})
+			return
+		}
+
+		if jobUpdate.MisfirePolicy != nil && !validators.ValidateMisfirePolicy(*jobUpdate.MisfirePolicy, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"misfirePolicy": "Invalid misfire policy",
+			})
+			return
+		}
+
+		if jobUpdate.ExecutionTimeoutAction != nil && !validators.ValidateTimeoutAction(*jobUpdate.ExecutionTimeoutAction, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"executionTimeoutAction": "Invalid execution timeout action",
+			})
+			return
+		}
+
+		if jobUpdate.RetryPolicy != nil && jobUpdate.RetryPolicy.Backoff != nil && !validators.ValidateBackoffStrategy(*jobUpdate.RetryPolicy.Backoff, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"retryPolicy": "Invalid backoff strategy",
+			})
+			return
+		}
+
+		if jobUpdate.WorkerPool != nil && !validators.ValidateWorkerPool(*jobUpdate.WorkerPool, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"workerPool": workerPoolError,
+			})
+			return
+		}
+
+		if jobUpdate.WorkerTags != nil && !validators.ValidateWorkerTags(*jobUpdate.WorkerTags) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"workerTags": workerTagsError,
+			})
+			return
+		}
+
+		if jobUpdate.DispatchMode != nil && !validators.ValidateDispatchMode(*jobUpdate.DispatchMode, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"dispatchMode": "Invalid dispatch mode",
+			})
+			return
+		}
+
 		cont := JobController{
-			jobRepo: jobRepo,
+			jobRepo:         jobRepo,
+			defaultTimeZone: defaultTimeZone,
 		}
 		cont.Edit(ctx, name, jobUpdate)
 	})
@@ -71,6 +218,95 @@ func RegisterControllers(router *gin.Engine, authCache *auth.AuthCache, jobRepo
 		}
 		cont.Delete(ctx, name)
 	})
+	jobs.GET("/:name/workflow", workflowsReadAuthHandler(authCache), func(ctx *gin.Context) {
+		name := ctx.Param("name")
+
+		var period *time.Time
+		if periodStr := ctx.Query("period"); periodStr != "" {
+			parsedPeriod, err := time.Parse(time.RFC3339, periodStr)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"period": "Invalid RFC3339 datetime",
+				})
+				return
+			}
+
+			period = &parsedPeriod
+		}
+
+		cont := WorkflowController{
+			jobRepo: jobRepo,
+			runRepo: runRepo,
+		}
+		cont.Read(ctx, name, period)
+	})
+	jobs.POST("/:name/runs", runsWriteAuthHandler(authCache), func(ctx *gin.Context) {
+		name := ctx.Param("name")
+
+		// The body is optional as parameters default to the job's
+		var runRequest dtos.RunRequest
+		if err := ctx.ShouldBindJSON(&runRequest); err != nil && !errors.Is(err, io.EOF) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if !validators.ValidateRunParameters(runRequest.Parameters) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"parameters": fmt.Sprintf("Invalid parameters, %s can only be set on the job", strings.Join(executorParameters.Reserved, ", ")),
+			})
+			return
+		}
+
+		cont := RunController{
+			runRepo: runRepo,
+			jobRepo: jobRepo,
+		}
+		cont.Add(ctx, name, runRequest)
+	})
+	jobs.POST("/:name/runs/lease", runsWriteAuthHandler(authCache), func(ctx *gin.Context) {
+		name := ctx.Param("name")
+
+		var leaseRequest dtos.RunLeaseRequest
+		if err := ctx.ShouldBindJSON(&leaseRequest); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if !validateLease(ctx, leaseRequest.WorkerId, leaseRequest.LeaseDuration) {
+			return
+		}
+
+		if !validators.ValidateWorkerPool(leaseRequest.Pool, true) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"pool": workerPoolError,
+			})
+			return
+		}
+
+		if !validators.ValidateWorkerTags(leaseRequest.Tags) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"tags": workerTagsError,
+			})
+			return
+		}
+
+		if leaseRequest.Wait < 0 || time.Duration(leaseRequest.Wait)*time.Millisecond > maxLeaseWait {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"wait": fmt.Sprintf("Invalid wait, must be between 0 and %d", maxLeaseWait.Milliseconds()),
+			})
+			return
+		}
+
+		cont := LeaseController{
+			runRepo: runRepo,
+			jobRepo: jobRepo,
+		}
+		cont.Lease(ctx, name, leaseRequest)
+	})
 
 	runs := api.Group("/runs")
 	runs.GET("", runsReadAuthHandler(authCache), func(ctx *gin.Context) {
@@ -100,15 +336,149 @@ func RegisterControllers(router *gin.Engine, authCache *auth.AuthCache, jobRepo
 		}
 		cont.Read(ctx, id)
 	})
-	runs.GET("/:id/cancel", runsWriteAuthHandler(authCache), func(ctx *gin.Context) {
+	runs.GET("/:id/logs", runsReadAuthHandler(authCache), func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		follow, err := strconv.ParseBool(ctx.DefaultQuery("follow", "false"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"follow": "Invalid boolean",
+			})
+			return
+		}
+
+		cont := RunLogController{
+			runRepo:    runRepo,
+			runLogRepo: runLogRepo,
+		}
+		if follow {
+			cont.Follow(ctx, id, ctx.Query("after"))
+		} else {
+			cont.Browse(ctx, id, ctx.Query("after"))
+		}
+	})
+	cancelRun := func(ctx *gin.Context) {
 		id := ctx.Param("id")
+
+		// The body is optional as the reason defaults to the requesting user
+		var cancelRequest dtos.RunCancelRequest
+		if err := ctx.ShouldBindJSON(&cancelRequest); err != nil && !errors.Is(err, io.EOF) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
 		cont := RunController{
+			runRepo:    runRepo,
+			jobRepo:    jobRepo,
+			messageBus: msgBus,
+			dispatcher: dispatcher,
+		}
+		cont.Cancel(ctx, id, cancelRequest)
+	}
+	runs.POST("/:id/cancel", runsWriteAuthHandler(authCache), cancelRun)
+	runs.GET("/:id/cancel", runsWriteAuthHandler(authCache), cancelRun)
+	runs.POST("/:id/lease", runsWriteAuthHandler(authCache), func(ctx *gin.Context) {
+		id := ctx.Param("id")
+
+		var renewal dtos.RunLeaseRenewal
+		if err := ctx.ShouldBindJSON(&renewal); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if !validateLease(ctx, renewal.WorkerId, renewal.LeaseDuration) {
+			return
+		}
+
+		if !validators.ValidateProgress(renewal.Progress) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"progress": "Invalid progress, must be between 0 and 100",
+			})
+			return
+		}
+
+		cont := LeaseController{
 			runRepo: runRepo,
 		}
-		cont.Cancel(ctx, id)
+		cont.Renew(ctx, id, renewal)
+	})
+	runs.POST("/:id/status", runsWriteAuthHandler(authCache), func(ctx *gin.Context) {
+		id := ctx.Param("id")
+
+		var report dtos.RunStatusReport
+		if err := ctx.ShouldBindJSON(&report); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if report.WorkerId == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"workerId": "Worker ID is required",
+			})
+			return
+		}
+
+		// Leased runs are already running so only their outcome is reported
+		status := runStatuses.RunStatus(report.Status)
+		if !runStatuses.IsClientStatus(status) || !runStatuses.IsFinished(status) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"status": "Invalid status, must be cancelled, completed or failed",
+			})
+			return
+		}
+
+		if !validators.ValidateProgress(report.Progress) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"progress": "Invalid progress, must be between 0 and 100",
+			})
+			return
+		}
+
+		cont := LeaseController{
+			runRepo: runRepo,
+		}
+		cont.ReportStatus(ctx, id, report)
+	})
+
+	workers := api.Group("/workers")
+	workers.GET("", jobsReadAuthHandler(authCache), func(ctx *gin.Context) {
+		filter := dtos.WorkerFilter{}
+		if jobName := ctx.Query("jobName"); jobName != "" {
+			filter.JobName = &jobName
+		}
+
+		cont := WorkerController{
+			workerRepo: workerRepo,
+		}
+		cont.Browse(ctx, filter)
 	})
 }
 
+// validateLease responds with a bad request if the worker or duration of a
+// lease request is invalid.
+func validateLease(ctx *gin.Context, workerId string, leaseDuration int) bool {
+	if workerId == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"workerId": "Worker ID is required",
+		})
+		return false
+	}
+
+	if leaseDuration <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"leaseDuration": "Invalid lease duration, must be greater than 0",
+		})
+		return false
+	}
+
+	return true
+}
+
 func jobsReadAuthHandler(authCache *auth.AuthCache) gin.HandlerFunc {
 	return middleware.AuthHandler(authCache, []string{"jobs:read"})
 }
@@ -124,3 +494,7 @@ func runsReadAuthHandler(authCache *auth.AuthCache) gin.HandlerFunc {
 func runsWriteAuthHandler(authCache *auth.AuthCache) gin.HandlerFunc {
 	return middleware.AuthHandler(authCache, []string{"runs:write"})
 }
+
+func workflowsReadAuthHandler(authCache *auth.AuthCache) gin.HandlerFunc {
+	return middleware.AuthHandler(authCache, []string{"jobs:read", "runs:read"})
+}
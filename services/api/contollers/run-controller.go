@@ -2,17 +2,28 @@ package controllers
 
 import (
 	"fmt"
+	"log"
+	"maps"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jacobmcgowan/simple-scheduler/services/api/middleware"
 	responseHelpers "github.com/jacobmcgowan/simple-scheduler/services/api/response-helpers"
 	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
 	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/jobActions"
+	messageBus "github.com/jacobmcgowan/simple-scheduler/shared/message-bus"
+	"github.com/jacobmcgowan/simple-scheduler/shared/outbox"
 	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workerPools"
 )
 
 type RunController struct {
-	runRepo repositories.RunRepository
+	runRepo    repositories.RunRepository
+	jobRepo    repositories.JobRepository
+	messageBus messageBus.MessageBus
+	dispatcher *outbox.Dispatcher
 }
 
 func (cont RunController) Browse(ctx *gin.Context, filter dtos.RunFilter) {
@@ -31,24 +42,80 @@ func (cont RunController) Read(ctx *gin.Context, id string) {
 	}
 }
 
-func (cont RunController) Cancel(ctx *gin.Context, id string) {
+func (cont RunController) Add(ctx *gin.Context, jobName string, runRequest dtos.RunRequest) {
+	job, err := cont.jobRepo.Read(jobName)
+	if err != nil {
+		responseHelpers.RespondWithError(ctx, err)
+		return
+	}
+
+	if !job.Enabled {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Job is disabled",
+		})
+		return
+	}
+
+	parameters := map[string]any{}
+	maps.Copy(parameters, job.Parameters)
+	maps.Copy(parameters, runRequest.Parameters)
+	if len(parameters) == 0 {
+		parameters = nil
+	}
+
+	now := time.Now()
+	run := dtos.Run{
+		JobName:     jobName,
+		Status:      runStatuses.Requested,
+		CreatedTime: now,
+		Period:      now,
+		Heartbeat:   now,
+		Attempt:     1,
+		Manual:      true,
+		Parameters:  parameters,
+	}
+
+	if id, err := cont.runRepo.Add(run); err == nil {
+		ctx.JSON(http.StatusCreated, gin.H{
+			"id": id,
+		})
+	} else {
+		responseHelpers.RespondWithError(ctx, err)
+	}
+}
+
+func (cont RunController) Cancel(ctx *gin.Context, id string, cancelRequest dtos.RunCancelRequest) {
 	run, err := cont.runRepo.Read(id)
 	if err != nil {
 		responseHelpers.RespondWithError(ctx, err)
 		return
 	}
 
+	user := ctx.GetString(middleware.UserKey)
+	reason := cancelRequest.Reason
+	if reason == "" {
+		reason = "cancelled by user"
+		if user != "" {
+			reason = fmt.Sprintf("cancelled by %s", user)
+		}
+	}
+
 	switch run.Status {
 	case runStatuses.Cancelled, runStatuses.Cancelling:
 		ctx.Status(http.StatusNoContent)
-	case runStatuses.Completed, runStatuses.Failed:
+	case runStatuses.Completed, runStatuses.Failed, runStatuses.Skipped:
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": "Run already finished",
 		})
-	case runStatuses.Pending, runStatuses.Running:
-		cancellingStatus := runStatuses.Cancelling
+	case runStatuses.Requested, runStatuses.Queued:
+		// These runs have not been sent to a runner so there is nothing to notify
+		cancelledStatus := runStatuses.Cancelled
+		endTime := time.Now()
 		runUpdate := dtos.RunUpdate{
-			Status: &cancellingStatus,
+			Status:      &cancelledStatus,
+			EndTime:     &endTime,
+			Reason:      &reason,
+			CancelledBy: &user,
 		}
 
 		if err := cont.runRepo.Edit(id, runUpdate); err == nil {
@@ -56,7 +123,63 @@ func (cont RunController) Cancel(ctx *gin.Context, id string) {
 		} else {
 			responseHelpers.RespondWithError(ctx, err)
 		}
+	case runStatuses.Pending, runStatuses.Running:
+		job, err := cont.jobRepo.Read(run.JobName)
+		if err != nil {
+			responseHelpers.RespondWithError(ctx, err)
+			return
+		}
+
+		cancellingStatus := runStatuses.Cancelling
+		cancelRequestedTime := time.Now()
+		runUpdate := dtos.RunUpdate{
+			Status:              &cancellingStatus,
+			Reason:              &reason,
+			CancelledBy:         &user,
+			CancelRequestedTime: &cancelRequestedTime,
+			Outbox: outbox.NewJobMessage(job, dtos.JobActionMessage{
+				Action: string(jobActions.Cancel),
+				Reason: reason,
+			}, cancelRequestedTime),
+		}
+
+		if err := cont.runRepo.Edit(id, runUpdate); err != nil {
+			responseHelpers.RespondWithError(ctx, err)
+			return
+		}
+
+		// The cancel action is stored with the run so the Scheduler managing
+		// the job publishes it if it cannot be published now. Workers leasing
+		// runs learn of the cancellation when they renew the lease instead.
+		if runUpdate.Outbox != nil {
+			if err := cont.dispatchCancelAction(run); err != nil {
+				log.Printf("Failed to dispatch cancel action for run %s, will retry: %s", run.Id, err)
+			}
+		}
+
+		ctx.Status(http.StatusNoContent)
 	default:
 		ctx.Error(fmt.Errorf("run in unexpected status %s", run.Status))
 	}
 }
+
+// dispatchCancelAction notifies the runners of the job that the run has been
+// cancelled, through the worker pool the run was routed to.
+func (cont RunController) dispatchCancelAction(run dtos.Run) error {
+	routingKey := run.RoutingKey
+	if routingKey == "" {
+		routingKey = workerPools.RoutingKey("", nil)
+	}
+
+	err := cont.messageBus.Register(
+		"scheduler.job."+run.JobName,
+		map[string][]string{
+			workerPools.ActionQueue(run.JobName, routingKey): {routingKey},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register job %s to message bus: %s", run.JobName, err)
+	}
+
+	return cont.dispatcher.DispatchRun(run.Id)
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	responseHelpers "github.com/jacobmcgowan/simple-scheduler/services/api/response-helpers"
+	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+)
+
+const followPollDuration = time.Second
+
+type RunLogController struct {
+	runRepo    repositories.RunRepository
+	runLogRepo repositories.RunLogRepository
+}
+
+func (cont RunLogController) Browse(ctx *gin.Context, runId string, after string) {
+	if _, err := cont.runRepo.Read(runId); err != nil {
+		responseHelpers.RespondWithError(ctx, err)
+		return
+	}
+
+	logs, err := cont.runLogRepo.Browse(logFilter(runId, after))
+	if err != nil {
+		responseHelpers.RespondWithError(ctx, err)
+		return
+	}
+
+	if logs == nil {
+		logs = []dtos.RunLog{}
+	}
+
+	ctx.JSON(http.StatusOK, logs)
+}
+
+// Follow streams the logs of a run as newline delimited JSON until the run has
+// finished and all of its logs have been sent, or the client disconnects.
+func (cont RunLogController) Follow(ctx *gin.Context, runId string, after string) {
+	if _, err := cont.runRepo.Read(runId); err != nil {
+		responseHelpers.RespondWithError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Type", "application/x-ndjson")
+	ctx.Status(http.StatusOK)
+	ctx.Stream(func(w io.Writer) bool {
+		// The status is read first so that logs sent before the run finished
+		// are included in the last poll
+		run, err := cont.runRepo.Read(runId)
+		if err != nil {
+			log.Printf("Failed to read run %s to follow its logs: %s", runId, err)
+			return false
+		}
+
+		logs, err := cont.runLogRepo.Browse(logFilter(runId, after))
+		if err != nil {
+			log.Printf("Failed to get logs of run %s: %s", runId, err)
+			return false
+		}
+
+		encoder := json.NewEncoder(w)
+		for _, runLog := range logs {
+			if err := encoder.Encode(runLog); err != nil {
+				return false
+			}
+
+			after = runLog.Id
+		}
+
+		if runStatuses.IsFinished(run.Status) && len(logs) == 0 {
+			return false
+		}
+
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-time.After(followPollDuration):
+			return true
+		}
+	})
+}
+
+func logFilter(runId string, after string) dtos.RunLogFilter {
+	filter := dtos.RunLogFilter{
+		RunId: &runId,
+	}
+	if after != "" {
+		filter.After = &after
+	}
+
+	return filter
+}
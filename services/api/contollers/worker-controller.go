@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	responseHelpers "github.com/jacobmcgowan/simple-scheduler/services/api/response-helpers"
+	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+)
+
+type WorkerController struct {
+	workerRepo repositories.WorkerRepository
+}
+
+func (cont WorkerController) Browse(ctx *gin.Context, filter dtos.WorkerFilter) {
+	workers, err := cont.workerRepo.Browse(filter)
+	if err != nil {
+		responseHelpers.RespondWithError(ctx, err)
+		return
+	}
+
+	if workers == nil {
+		workers = []dtos.Worker{}
+	}
+
+	ctx.JSON(http.StatusOK, workers)
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	responseHelpers "github.com/jacobmcgowan/simple-scheduler/services/api/response-helpers"
+	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workflows"
+)
+
+type WorkflowController struct {
+	jobRepo repositories.JobRepository
+	runRepo repositories.RunRepository
+}
+
+// Read returns the state of the workflow containing the job for a period. If
+// no period is given, the latest period of the workflow's first jobs is used.
+func (cont WorkflowController) Read(ctx *gin.Context, jobName string, period *time.Time) {
+	jobs, err := cont.jobRepo.Browse()
+	if err != nil {
+		responseHelpers.RespondWithError(ctx, err)
+		return
+	}
+
+	workflowJobs := workflows.Component(jobName, jobs)
+	if len(workflowJobs) == 0 {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+
+	if period == nil {
+		latest, err := cont.latestPeriod(workflowJobs)
+		if err != nil {
+			responseHelpers.RespondWithError(ctx, err)
+			return
+		}
+
+		period = &latest
+	}
+
+	runs := []dtos.Run{}
+	for _, job := range workflowJobs {
+		filter := dtos.RunFilter{
+			JobName: &job.Name,
+			Period:  period,
+		}
+		jobRuns, err := cont.runRepo.Browse(filter)
+		if err != nil {
+			responseHelpers.RespondWithError(ctx, err)
+			return
+		}
+
+		runs = append(runs, jobRuns...)
+	}
+
+	ctx.JSON(http.StatusOK, dtos.Workflow{
+		Period: *period,
+		Nodes:  workflows.Nodes(workflowJobs, runs),
+	})
+}
+
+func (cont WorkflowController) latestPeriod(jobs []dtos.Job) (time.Time, error) {
+	var latest time.Time
+	for _, job := range jobs {
+		if len(job.DependsOn) > 0 {
+			continue
+		}
+
+		filter := dtos.RunFilter{
+			JobName: &job.Name,
+		}
+		runs, err := cont.runRepo.Browse(filter)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		for _, run := range runs {
+			if run.Period.After(latest) {
+				latest = run.Period
+			}
+		}
+	}
+
+	return latest, nil
+}
@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jacobmcgowan/simple-scheduler/services/api/auth"
@@ -31,6 +32,11 @@ func main() {
 		log.Fatalf("Failed to load environment file, %s", envFilename)
 	}
 
+	defaultTimeZone, err := time.LoadLocation(os.Getenv(envVars.DefaultTimeZone))
+	if err != nil {
+		log.Fatalf("Default time zone invalid: %s", err)
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
@@ -47,13 +53,26 @@ func main() {
 	defer dbResources.Context.Disconnect()
 	log.Println("Connected to database")
 
+	msgBusEnv := resources.LoadMessageBusEnv()
+	msgBusResources, err := resources.RegisterMessageBus(msgBusEnv)
+	if err != nil {
+		log.Fatalf("Failed to register message bus: %s", err)
+	}
+
+	log.Printf("Connecting to message bus %s...", msgBusResources.Name)
+	if err = msgBusResources.MessageBus.Connect(); err != nil {
+		log.Fatalf("Failed to connect to message bus: %s", err)
+	}
+	defer msgBusResources.MessageBus.Close()
+	log.Println("Connected to message bus")
+
 	authCache := &auth.AuthCache{
 		Issuer: os.Getenv(envVars.OidcIssuer),
 	}
 	router := gin.Default()
 	router.Use(middleware.ErrorHandler())
 	router.Use(gin.Recovery())
-	controllers.RegisterControllers(router, authCache, dbResources.JobRepo, dbResources.RunRepo)
+	controllers.RegisterControllers(router, authCache, defaultTimeZone, dbResources.JobRepo, dbResources.RunRepo, dbResources.RunLogRepo, dbResources.WorkerRepo, msgBusResources.MessageBus)
 
 	srv := &http.Server{
 		Addr:    os.Getenv(envVars.ApiUrl),
@@ -16,6 +16,10 @@ import (
 	"github.com/jacobmcgowan/simple-scheduler/services/api/auth"
 )
 
+// UserKey is the context key of the name of the user the access token was
+// issued to.
+const UserKey = "user"
+
 func AuthHandler(cache *auth.AuthCache, reqScopes []string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader("Authorization")
@@ -101,6 +105,12 @@ func AuthHandler(cache *auth.AuthCache, reqScopes []string) gin.HandlerFunc {
 			return
 		}
 
+		if user, ok := claims["preferred_username"].(string); ok && user != "" {
+			ctx.Set(UserKey, user)
+		} else if sub, err := claims.GetSubject(); err == nil {
+			ctx.Set(UserKey, sub)
+		}
+
 		ctx.Next()
 	}
 }
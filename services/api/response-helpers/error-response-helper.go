@@ -10,8 +10,18 @@ import (
 
 func RespondWithError(ctx *gin.Context, err error) {
 	var notFoundErr *repositoryErrors.NotFoundError
+	var invalidTransitionErr *repositoryErrors.InvalidTransitionError
+	var leaseLostErr *repositoryErrors.LeaseLostError
 	if errors.As(err, &notFoundErr) {
 		ctx.Status(http.StatusNotFound)
+	} else if errors.As(err, &invalidTransitionErr) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": invalidTransitionErr.Error(),
+		})
+	} else if errors.As(err, &leaseLostErr) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": leaseLostErr.Error(),
+		})
 	} else {
 		ctx.Error(err)
 	}
@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/jacobmcgowan/simple-scheduler/services/cli/cmd/options"
 	"github.com/jacobmcgowan/simple-scheduler/services/cli/services"
 	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/validators"
 	"github.com/spf13/cobra"
 )
 
@@ -18,9 +20,48 @@ var addJobCmd = &cobra.Command{
 	Short:   "Adds a job",
 	Long:    `Schedules a job.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		nextRunAtTime, err := time.Parse(time.RFC3339, addJobOptions.NextRunAt)
-		if err != nil {
-			return fmt.Errorf("nextRunAt, %s, is not a valid RFC3339 datetime", addJobOptions.NextRunAt)
+		if addJobOptions.NextRunAt == "" && addJobOptions.Schedule == "" {
+			return fmt.Errorf("either next-run-at or schedule is required")
+		}
+
+		if !validators.ValidateSchedule(addJobOptions.Schedule, true) {
+			return fmt.Errorf("schedule, %s, is not a valid cron expression", addJobOptions.Schedule)
+		}
+
+		if !validators.ValidateConcurrencyPolicy(addJobOptions.ConcurrencyPolicy, true) {
+			return fmt.Errorf("concurrency policy, %s, must be skip or queue", addJobOptions.ConcurrencyPolicy)
+		}
+
+		if !validators.ValidateMisfirePolicy(addJobOptions.MisfirePolicy, true) {
+			return fmt.Errorf("misfire policy, %s, must be fireAll, fireOnce or skip", addJobOptions.MisfirePolicy)
+		}
+
+		if !validators.ValidateTimeoutAction(addJobOptions.ExecutionTimeoutAction, true) {
+			return fmt.Errorf("execution timeout action, %s, must be cancel, fail or notify", addJobOptions.ExecutionTimeoutAction)
+		}
+
+		if !validators.ValidateDispatchMode(addJobOptions.DispatchMode, true) {
+			return fmt.Errorf("dispatch mode, %s, must be push or lease", addJobOptions.DispatchMode)
+		}
+
+		if !validators.ValidateBackoffStrategy(addJobOptions.Backoff, true) {
+			return fmt.Errorf("backoff, %s, must be fixed or exponential", addJobOptions.Backoff)
+		}
+
+		var parameters map[string]any
+		if addJobOptions.Parameters != "" {
+			if err := json.Unmarshal([]byte(addJobOptions.Parameters), &parameters); err != nil {
+				return fmt.Errorf("parameters, %s, is not a valid JSON object", addJobOptions.Parameters)
+			}
+		}
+
+		var nextRunAtTime time.Time
+		if addJobOptions.NextRunAt != "" {
+			var err error
+			nextRunAtTime, err = time.Parse(time.RFC3339, addJobOptions.NextRunAt)
+			if err != nil {
+				return fmt.Errorf("nextRunAt, %s, is not a valid RFC3339 datetime", addJobOptions.NextRunAt)
+			}
 		}
 
 		authSvc := services.AuthService{}
@@ -30,15 +71,35 @@ var addJobCmd = &cobra.Command{
 		}
 
 		job := dtos.Job{
-			Name:                addJobOptions.Name,
-			Enabled:             addJobOptions.Enabled,
-			NextRunAt:           nextRunAtTime,
-			Interval:            addJobOptions.Interval,
-			RunExecutionTimeout: addJobOptions.RunExecutionTimeout,
-			RunStartTimeout:     addJobOptions.RunStartTimeout,
-			MaxQueueCount:       addJobOptions.MaxQueueCount,
-			AllowConcurrentRuns: addJobOptions.AllowConcurrentRuns,
-			HeartbeatTimeout:    addJobOptions.HeartbeatTimeout,
+			Name:                   addJobOptions.Name,
+			Enabled:                addJobOptions.Enabled,
+			NextRunAt:              nextRunAtTime,
+			Interval:               addJobOptions.Interval,
+			Schedule:               addJobOptions.Schedule,
+			TimeZone:               addJobOptions.TimeZone,
+			RunExecutionTimeout:    addJobOptions.RunExecutionTimeout,
+			RunStartTimeout:        addJobOptions.RunStartTimeout,
+			ExecutionTimeoutAction: addJobOptions.ExecutionTimeoutAction,
+			CancelGracePeriod:      addJobOptions.CancelGracePeriod,
+			MaxQueueCount:          addJobOptions.MaxQueueCount,
+			AllowConcurrentRuns:    addJobOptions.AllowConcurrentRuns,
+			ConcurrencyPolicy:      addJobOptions.ConcurrencyPolicy,
+			MisfirePolicy:          addJobOptions.MisfirePolicy,
+			MisfireThreshold:       addJobOptions.MisfireThreshold,
+			RetryPolicy: dtos.RetryPolicy{
+				MaxAttempts:    addJobOptions.MaxAttempts,
+				Backoff:        addJobOptions.Backoff,
+				Delay:          addJobOptions.RetryDelay,
+				MaxDelay:       addJobOptions.MaxRetryDelay,
+				RetryOnTimeout: addJobOptions.RetryOnTimeout,
+			},
+			Parameters:       parameters,
+			DependsOn:        addJobOptions.DependsOn,
+			HeartbeatTimeout: addJobOptions.HeartbeatTimeout,
+			MaxRestarts:      addJobOptions.MaxRestarts,
+			WorkerPool:       addJobOptions.WorkerPool,
+			WorkerTags:       addJobOptions.WorkerTags,
+			DispatchMode:     addJobOptions.DispatchMode,
 		}
 		jobSvc := services.JobService{
 			ApiUrl:      ApiUrl,
@@ -58,12 +119,29 @@ func init() {
 	addJobCmd.Flags().StringVarP(&addJobOptions.Name, "name", "n", "", "The name of the job.")
 	addJobCmd.MarkFlagRequired("name")
 	addJobCmd.Flags().BoolVarP(&addJobOptions.Enabled, "enabled", "e", true, "Whether the job is enabled.")
-	addJobCmd.Flags().StringVarP(&addJobOptions.NextRunAt, "next-run-at", "r", "", "The next time the job should run.")
-	addJobCmd.MarkFlagRequired("next-run-at")
+	addJobCmd.Flags().StringVarP(&addJobOptions.NextRunAt, "next-run-at", "r", "", "The next time the job should run. Required unless a schedule or dependencies are set.")
 	addJobCmd.Flags().IntVarP(&addJobOptions.Interval, "interval", "i", 0, "The interval to run the job in milliseconds.")
+	addJobCmd.Flags().StringVar(&addJobOptions.Schedule, "schedule", "", "The cron expression to run the job on, e.g. \"15 2 * * MON-FRI\". Takes precedence over the interval.")
+	addJobCmd.Flags().StringVar(&addJobOptions.TimeZone, "time-zone", "", "The IANA time zone to evaluate the schedule in, e.g. \"America/New_York\". Defaults to the server's time zone.")
 	addJobCmd.Flags().IntVarP(&addJobOptions.RunExecutionTimeout, "run-execution-timeout", "x", 0, "The time in milliseconds to wait for each run to complete.")
 	addJobCmd.Flags().IntVarP(&addJobOptions.RunStartTimeout, "run-start-timeout", "s", 0, "The time in milliseconds to wait for each run to start to start.")
+	addJobCmd.Flags().StringVar(&addJobOptions.ExecutionTimeoutAction, "execution-timeout-action", "", "What to do with runs that exceed the run execution timeout (cancel|fail|notify). Defaults to cancel.")
+	addJobCmd.Flags().IntVar(&addJobOptions.CancelGracePeriod, "cancel-grace-period", 0, "The time in milliseconds to wait for a cancelling run to be cancelled before it is forced to cancelled. If 0, the Scheduler's default is used.")
 	addJobCmd.Flags().IntVarP(&addJobOptions.MaxQueueCount, "max-queue-count", "q", 0, "The maximum number of runs that can be queued.")
 	addJobCmd.Flags().BoolVarP(&addJobOptions.AllowConcurrentRuns, "allow-concurrent-runs", "c", false, "Whether to allow concurrent runs of the job.")
+	addJobCmd.Flags().StringVar(&addJobOptions.ConcurrencyPolicy, "concurrency-policy", "", "What to do when a run is due while another is active and concurrent runs are not allowed (skip|queue). Defaults to skip.")
+	addJobCmd.Flags().StringVar(&addJobOptions.MisfirePolicy, "misfire-policy", "", "What to do with occurrences missed by more than the misfire threshold (fireAll|fireOnce|skip). Defaults to fireOnce.")
+	addJobCmd.Flags().IntVar(&addJobOptions.MisfireThreshold, "misfire-threshold", 0, "The time in milliseconds a run may start late before it is considered missed. Defaults to 60000.")
+	addJobCmd.Flags().IntVar(&addJobOptions.MaxAttempts, "max-attempts", 0, "The maximum number of attempts for each run, including the first. Runs are not retried if 1 or less.")
+	addJobCmd.Flags().StringVar(&addJobOptions.Backoff, "backoff", "", "How the delay between attempts grows (fixed|exponential). Defaults to fixed.")
+	addJobCmd.Flags().IntVar(&addJobOptions.RetryDelay, "retry-delay", 0, "The time in milliseconds to wait before the first retry.")
+	addJobCmd.Flags().IntVar(&addJobOptions.MaxRetryDelay, "max-retry-delay", 0, "The maximum time in milliseconds to wait between attempts. Unlimited if 0.")
+	addJobCmd.Flags().BoolVar(&addJobOptions.RetryOnTimeout, "retry-on-timeout", false, "Whether to retry runs cancelled because they timed out.")
+	addJobCmd.Flags().StringVar(&addJobOptions.Parameters, "parameters", "", "The default parameters of the job's runs as a JSON object, e.g. '{\"region\": \"eu\"}'.")
+	addJobCmd.Flags().StringSliceVar(&addJobOptions.DependsOn, "depends-on", nil, "The names of the jobs that must complete before each run of the job, e.g. \"extract,transform\".")
 	addJobCmd.Flags().IntVarP(&addJobOptions.HeartbeatTimeout, "heartbeat-timeout", "t", 0, "The time in milliseconds to wait for each heartbeat of a run.")
+	addJobCmd.Flags().IntVar(&addJobOptions.MaxRestarts, "max-restarts", 0, "The maximum number of times a run is restarted after a heartbeat timeout before it is failed. Defaults to 3.")
+	addJobCmd.Flags().StringVar(&addJobOptions.WorkerPool, "worker-pool", "", "The pool of workers that perform the job's runs. Defaults to the default pool.")
+	addJobCmd.Flags().StringSliceVar(&addJobOptions.WorkerTags, "worker-tags", nil, "The tags a worker must have to perform the job's runs, e.g. \"gpu,eu\".")
+	addJobCmd.Flags().StringVar(&addJobOptions.DispatchMode, "dispatch-mode", "", "How runs reach workers, published to the message bus or leased through the API (push|lease). Defaults to push.")
 }
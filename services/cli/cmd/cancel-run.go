@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jacobmcgowan/simple-scheduler/services/cli/cmd/options"
+	"github.com/jacobmcgowan/simple-scheduler/services/cli/services"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/spf13/cobra"
+)
+
+var cancelRunOptions = options.CancelRunOptions{}
+
+var cancelRunCmd = &cobra.Command{
+	Use:     "run",
+	Aliases: []string{"r"},
+	Short:   "Cancels a run",
+	Long: `Cancels a run. Runs that have been published are set to cancelling and
+a cancel action is sent to the job's runners. The reason and the current user
+are recorded on the run.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authSvc := services.AuthService{}
+		token, err := authSvc.GetAccessToken()
+		if err != nil {
+			return fmt.Errorf("failed to get access token: %s", err)
+		}
+
+		svc := services.RunService{
+			ApiUrl:      ApiUrl,
+			AccessToken: token,
+		}
+
+		cancelRequest := dtos.RunCancelRequest{
+			Reason: cancelRunOptions.Reason,
+		}
+		if err := svc.Cancel(cancelRunOptions.Id, cancelRequest); err != nil {
+			return fmt.Errorf("failed to cancel run: %s", err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	cancelCmd.AddCommand(cancelRunCmd)
+	cancelRunCmd.Flags().StringVarP(&cancelRunOptions.Id, "id", "i", "", "The ID of the run.")
+	cancelRunCmd.MarkFlagRequired("id")
+	cancelRunCmd.Flags().StringVarP(&cancelRunOptions.Reason, "reason", "r", "", "Why the run is being cancelled. Defaults to the current user.")
+}
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cancelCmd = &cobra.Command{
+	Use:     "cancel",
+	Aliases: []string{"c"},
+	Short:   "Cancels an item",
+	Long:    `Cancels an item, such as a run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cancelCmd)
+}
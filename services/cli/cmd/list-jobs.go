@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/jacobmcgowan/simple-scheduler/services/cli/services"
@@ -30,21 +31,35 @@ var jobsCmd = &cobra.Command{
 			writer := tabwriter.NewWriter(os.Stdout, 1, 1, 4, ' ', 0)
 			fmt.Fprintln(
 				writer,
-				"NAME\tENABLED\tNEXT RUN AT\tINTERVAL\tRUN EXECUTION TIMEOUT\tRUN START TIMEOUT\tMAX QUEUE COUNT\tALLOW CONCURRENT RUNS\tHEARTBEAT TIMEOUT")
+				"NAME\tENABLED\tNEXT RUN AT\tINTERVAL\tSCHEDULE\tTIME ZONE\tRUN EXECUTION TIMEOUT\tRUN START TIMEOUT\tEXECUTION TIMEOUT ACTION\tCANCEL GRACE PERIOD\tMAX QUEUE COUNT\tALLOW CONCURRENT RUNS\tCONCURRENCY POLICY\tMISFIRE POLICY\tMISFIRE THRESHOLD\tMAX ATTEMPTS\tBACKOFF\tDEPENDS ON\tHEARTBEAT TIMEOUT\tMAX RESTARTS\tWORKER POOL\tWORKER TAGS\tDISPATCH MODE")
 
 			for _, job := range jobs {
 				fmt.Fprintf(
 					writer,
-					"%s\t%t\t%s\t%d\t%d\t%d\t%d\t%t\t%d\n",
+					"%s\t%t\t%s\t%d\t%s\t%s\t%d\t%d\t%s\t%d\t%d\t%t\t%s\t%s\t%d\t%d\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n",
 					job.Name,
 					job.Enabled,
 					job.NextRunAt,
 					job.Interval,
+					job.Schedule,
+					job.TimeZone,
 					job.RunExecutionTimeout,
 					job.RunStartTimeout,
+					job.ExecutionTimeoutAction,
+					job.CancelGracePeriod,
 					job.MaxQueueCount,
 					job.AllowConcurrentRuns,
-					job.HeartbeatTimeout)
+					job.ConcurrencyPolicy,
+					job.MisfirePolicy,
+					job.MisfireThreshold,
+					job.RetryPolicy.MaxAttempts,
+					job.RetryPolicy.Backoff,
+					strings.Join(job.DependsOn, ","),
+					job.HeartbeatTimeout,
+					job.MaxRestarts,
+					job.WorkerPool,
+					strings.Join(job.WorkerTags, ","),
+					job.DispatchMode)
 			}
 
 			writer.Flush()
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"text/tabwriter"
 
 	"github.com/jacobmcgowan/simple-scheduler/services/cli/cmd/options"
@@ -15,13 +17,16 @@ import (
 
 var listRunsOptions = options.RunFilterOptions{}
 
-var statusChoices = fmt.Sprintf("%s|%s|%s|%s|%s|%s",
+var statusChoices = fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%s|%s",
+	runStatuses.Requested,
+	runStatuses.Queued,
 	runStatuses.Pending,
 	runStatuses.Running,
 	runStatuses.Cancelling,
 	runStatuses.Cancelled,
 	runStatuses.Failed,
-	runStatuses.Completed)
+	runStatuses.Completed,
+	runStatuses.Skipped)
 
 var runsCmd = &cobra.Command{
 	Use:     "runs",
@@ -56,10 +61,10 @@ scheduled.`,
 
 		if runs, err := svc.Browse(filter); err == nil {
 			writer := tabwriter.NewWriter(os.Stdout, 1, 1, 4, ' ', 0)
-			fmt.Fprintln(writer, "ID\tJOB\tSTATUS\tSTART TIME\tEND TIME")
+			fmt.Fprintln(writer, "ID\tJOB\tSTATUS\tATTEMPT\tRESTARTS\tSTART TIME\tEND TIME\tREASON\tCANCELLED BY\tPROGRESS\tEXIT CODE\tERROR\tRESULT")
 
 			for _, run := range runs {
-				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", run.Id, run.JobName, run.Status, run.StartTime, run.EndTime)
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", run.Id, run.JobName, run.Status, run.Attempt, len(run.Restarts), run.StartTime, run.EndTime, run.Reason, run.CancelledBy, formatProgress(run), formatExitCode(run), formatError(run), formatResult(run))
 			}
 
 			writer.Flush()
@@ -71,6 +76,47 @@ scheduled.`,
 	},
 }
 
+func formatProgress(run dtos.Run) string {
+	if run.Progress == 0 && run.ProgressMessage == "" {
+		return ""
+	}
+
+	if run.ProgressMessage == "" {
+		return fmt.Sprintf("%g%%", run.Progress)
+	}
+
+	return fmt.Sprintf("%g%% %s", run.Progress, run.ProgressMessage)
+}
+
+func formatExitCode(run dtos.Run) string {
+	if run.ExitCode == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*run.ExitCode)
+}
+
+func formatError(run dtos.Run) string {
+	if run.ErrorCode == "" {
+		return run.Error
+	}
+
+	return fmt.Sprintf("%s: %s", run.ErrorCode, run.Error)
+}
+
+func formatResult(run dtos.Run) string {
+	if run.Result == nil {
+		return ""
+	}
+
+	result, err := json.Marshal(run.Result)
+	if err != nil {
+		return ""
+	}
+
+	return string(result)
+}
+
 func init() {
 	listCmd.AddCommand(runsCmd)
 	runsCmd.Flags().StringVarP(&listRunsOptions.JobName, "job", "j", "", "The job to list the runs for.")
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jacobmcgowan/simple-scheduler/services/cli/cmd/options"
+	"github.com/jacobmcgowan/simple-scheduler/services/cli/services"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/spf13/cobra"
+)
+
+var listWorkersOptions = options.WorkerFilterOptions{}
+
+var workersCmd = &cobra.Command{
+	Use:     "workers",
+	Aliases: []string{"w"},
+	Short:   "Lists workers",
+	Long: `Provides details on the workers that are registered with the
+Scheduler and how many runs they are performing.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter := dtos.WorkerFilter{}
+		if listWorkersOptions.JobName != "" {
+			filter.JobName = &listWorkersOptions.JobName
+		}
+
+		authSvc := services.AuthService{}
+		token, err := authSvc.GetAccessToken()
+		if err != nil {
+			return fmt.Errorf("failed to get access token: %s", err)
+		}
+
+		svc := services.WorkerService{
+			ApiUrl:      ApiUrl,
+			AccessToken: token,
+		}
+
+		if workers, err := svc.Browse(filter); err == nil {
+			writer := tabwriter.NewWriter(os.Stdout, 1, 1, 4, ' ', 0)
+			fmt.Fprintln(writer, "ID\tHOSTNAME\tJOBS\tPOOL\tTAGS\tACTIVE RUNS\tCAPACITY\tVERSION\tREGISTERED TIME\tHEARTBEAT")
+
+			for _, worker := range workers {
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n", worker.Id, worker.Hostname, strings.Join(worker.JobNames, ","), worker.Pool, strings.Join(worker.Tags, ","), worker.ActiveRuns, worker.Capacity, worker.Version, worker.RegisteredTime, worker.Heartbeat)
+			}
+
+			writer.Flush()
+		} else {
+			return fmt.Errorf("failed to get workers: %s", err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	listCmd.AddCommand(workersCmd)
+	workersCmd.Flags().StringVarP(&listWorkersOptions.JobName, "job", "j", "", "The job to list the workers for.")
+}
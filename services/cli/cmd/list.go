@@ -7,8 +7,8 @@ import (
 var listCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"l", "ls"},
-	Short:   "Lists jobs or runs",
-	Long:    `Provides details on current jobs or runs.`,
+	Short:   "Lists jobs, runs or workers",
+	Long:    `Provides details on current jobs, runs or workers.`,
 	Run: func(cmd *cobra.Command, args []string) {
 	},
 }
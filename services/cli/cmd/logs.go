@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/services/cli/cmd/options"
+	"github.com/jacobmcgowan/simple-scheduler/services/cli/services"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/spf13/cobra"
+)
+
+var logsOptions = options.LogsOptions{}
+
+var logsCmd = &cobra.Command{
+	Use:     "logs <runId>",
+	Aliases: []string{"lg"},
+	Short:   "Prints the logs of a run",
+	Long: `Prints the log lines sent by the job's runners for a run. With --follow,
+new lines are printed as they arrive until the run has finished.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		authSvc := services.AuthService{}
+		token, err := authSvc.GetAccessToken()
+		if err != nil {
+			return fmt.Errorf("failed to get access token: %s", err)
+		}
+
+		svc := services.RunService{
+			ApiUrl:      ApiUrl,
+			AccessToken: token,
+		}
+
+		if logsOptions.Follow {
+			if err := svc.FollowLogs(args[0], printLog); err != nil {
+				return fmt.Errorf("failed to follow logs: %s", err)
+			}
+
+			return nil
+		}
+
+		logs, err := svc.Logs(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to get logs: %s", err)
+		}
+
+		for _, runLog := range logs {
+			printLog(runLog)
+		}
+
+		return nil
+	},
+}
+
+func printLog(runLog dtos.RunLog) {
+	level := runLog.Level
+	if level == "" {
+		level = "info"
+	}
+
+	fmt.Printf("%s %-5s %s\n", runLog.Time.Format(time.RFC3339), level, runLog.Message)
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().BoolVarP(&logsOptions.Follow, "follow", "f", false, "Whether to keep printing new lines until the run has finished.")
+}
@@ -0,0 +1,6 @@
+package options
+
+type CancelRunOptions struct {
+	Id     string
+	Reason string
+}
@@ -1,13 +1,31 @@
 package options
 
 type JobOptions struct {
-	Name                string
-	Enabled             bool
-	NextRunAt           string
-	Interval            int
-	RunExecutionTimeout int
-	RunStartTimeout     int
-	MaxQueueCount       int
-	AllowConcurrentRuns bool
-	HeartbeatTimeout    int
+	Name                   string
+	Enabled                bool
+	NextRunAt              string
+	Interval               int
+	Schedule               string
+	TimeZone               string
+	RunExecutionTimeout    int
+	RunStartTimeout        int
+	ExecutionTimeoutAction string
+	CancelGracePeriod      int
+	MaxQueueCount          int
+	AllowConcurrentRuns    bool
+	ConcurrencyPolicy      string
+	MisfirePolicy          string
+	MisfireThreshold       int
+	MaxAttempts            int
+	Backoff                string
+	RetryDelay             int
+	MaxRetryDelay          int
+	RetryOnTimeout         bool
+	Parameters             string
+	DependsOn              []string
+	HeartbeatTimeout       int
+	MaxRestarts            int
+	WorkerPool             string
+	WorkerTags             []string
+	DispatchMode           string
 }
@@ -0,0 +1,5 @@
+package options
+
+type LogsOptions struct {
+	Follow bool
+}
@@ -0,0 +1,6 @@
+package options
+
+type RunJobOptions struct {
+	Name       string
+	Parameters string
+}
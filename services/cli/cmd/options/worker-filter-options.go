@@ -0,0 +1,5 @@
+package options
+
+type WorkerFilterOptions struct {
+	JobName string
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jacobmcgowan/simple-scheduler/services/cli/cmd/options"
+	"github.com/jacobmcgowan/simple-scheduler/services/cli/services"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/spf13/cobra"
+)
+
+var runJobOptions = options.RunJobOptions{}
+
+var runJobCmd = &cobra.Command{
+	Use:     "job",
+	Aliases: []string{"j"},
+	Short:   "Runs a job now",
+	Long: `Starts a run of a job outside of its schedule. The job's next scheduled
+run is not changed. Parameters given are merged with the job's parameters. The ID of the new run is printed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runRequest := dtos.RunRequest{}
+		if runJobOptions.Parameters != "" {
+			if err := json.Unmarshal([]byte(runJobOptions.Parameters), &runRequest.Parameters); err != nil {
+				return fmt.Errorf("parameters, %s, is not a valid JSON object", runJobOptions.Parameters)
+			}
+		}
+
+		authSvc := services.AuthService{}
+		token, err := authSvc.GetAccessToken()
+		if err != nil {
+			return fmt.Errorf("failed to get access token: %s", err)
+		}
+
+		svc := services.RunService{
+			ApiUrl:      ApiUrl,
+			AccessToken: token,
+		}
+
+		runId, err := svc.Add(runJobOptions.Name, runRequest)
+		if err != nil {
+			return fmt.Errorf("failed to run job: %s", err)
+		}
+
+		fmt.Println(runId)
+		return nil
+	},
+}
+
+func init() {
+	runCmd.AddCommand(runJobCmd)
+	runJobCmd.Flags().StringVarP(&runJobOptions.Name, "name", "n", "", "The name of the job.")
+	runJobCmd.MarkFlagRequired("name")
+	runJobCmd.Flags().StringVarP(&runJobOptions.Parameters, "parameters", "p", "", "Parameters of the run as a JSON object. These override the job's parameters with the same name.")
+}
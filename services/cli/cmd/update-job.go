@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/jacobmcgowan/simple-scheduler/services/cli/cmd/options"
 	"github.com/jacobmcgowan/simple-scheduler/services/cli/services"
 	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/validators"
 	"github.com/spf13/cobra"
 )
 
@@ -27,21 +29,110 @@ enabled status of the job named "myjob".`,
 		if cmd.Flags().Changed("interval") {
 			jobUpdate.Interval = &updateJobOptions.Interval
 		}
+		if cmd.Flags().Changed("schedule") {
+			if !validators.ValidateSchedule(updateJobOptions.Schedule, true) {
+				return fmt.Errorf("schedule, %s, is not a valid cron expression", updateJobOptions.Schedule)
+			}
+
+			jobUpdate.Schedule = &updateJobOptions.Schedule
+		}
+		if cmd.Flags().Changed("time-zone") {
+			jobUpdate.TimeZone = &updateJobOptions.TimeZone
+		}
 		if cmd.Flags().Changed("run-execution-timeout") {
 			jobUpdate.RunExecutionTimeout = &updateJobOptions.RunExecutionTimeout
 		}
 		if cmd.Flags().Changed("run-start-timeout") {
 			jobUpdate.RunStartTimeout = &updateJobOptions.RunStartTimeout
 		}
+		if cmd.Flags().Changed("execution-timeout-action") {
+			if !validators.ValidateTimeoutAction(updateJobOptions.ExecutionTimeoutAction, true) {
+				return fmt.Errorf("execution timeout action, %s, must be cancel, fail or notify", updateJobOptions.ExecutionTimeoutAction)
+			}
+
+			jobUpdate.ExecutionTimeoutAction = &updateJobOptions.ExecutionTimeoutAction
+		}
+		if cmd.Flags().Changed("cancel-grace-period") {
+			jobUpdate.CancelGracePeriod = &updateJobOptions.CancelGracePeriod
+		}
 		if cmd.Flags().Changed("max-queue-count") {
 			jobUpdate.MaxQueueCount = &updateJobOptions.MaxQueueCount
 		}
 		if cmd.Flags().Changed("allow-concurrent-runs") {
 			jobUpdate.AllowConcurrentRuns = &updateJobOptions.AllowConcurrentRuns
 		}
+		if cmd.Flags().Changed("concurrency-policy") {
+			if !validators.ValidateConcurrencyPolicy(updateJobOptions.ConcurrencyPolicy, true) {
+				return fmt.Errorf("concurrency policy, %s, must be skip or queue", updateJobOptions.ConcurrencyPolicy)
+			}
+
+			jobUpdate.ConcurrencyPolicy = &updateJobOptions.ConcurrencyPolicy
+		}
+		if cmd.Flags().Changed("misfire-policy") {
+			if !validators.ValidateMisfirePolicy(updateJobOptions.MisfirePolicy, true) {
+				return fmt.Errorf("misfire policy, %s, must be fireAll, fireOnce or skip", updateJobOptions.MisfirePolicy)
+			}
+
+			jobUpdate.MisfirePolicy = &updateJobOptions.MisfirePolicy
+		}
+		if cmd.Flags().Changed("misfire-threshold") {
+			jobUpdate.MisfireThreshold = &updateJobOptions.MisfireThreshold
+		}
+		retryPolicyUpdate := dtos.RetryPolicyUpdate{}
+		if cmd.Flags().Changed("max-attempts") {
+			retryPolicyUpdate.MaxAttempts = &updateJobOptions.MaxAttempts
+			jobUpdate.RetryPolicy = &retryPolicyUpdate
+		}
+		if cmd.Flags().Changed("backoff") {
+			if !validators.ValidateBackoffStrategy(updateJobOptions.Backoff, true) {
+				return fmt.Errorf("backoff, %s, must be fixed or exponential", updateJobOptions.Backoff)
+			}
+
+			retryPolicyUpdate.Backoff = &updateJobOptions.Backoff
+			jobUpdate.RetryPolicy = &retryPolicyUpdate
+		}
+		if cmd.Flags().Changed("retry-delay") {
+			retryPolicyUpdate.Delay = &updateJobOptions.RetryDelay
+			jobUpdate.RetryPolicy = &retryPolicyUpdate
+		}
+		if cmd.Flags().Changed("max-retry-delay") {
+			retryPolicyUpdate.MaxDelay = &updateJobOptions.MaxRetryDelay
+			jobUpdate.RetryPolicy = &retryPolicyUpdate
+		}
+		if cmd.Flags().Changed("retry-on-timeout") {
+			retryPolicyUpdate.RetryOnTimeout = &updateJobOptions.RetryOnTimeout
+			jobUpdate.RetryPolicy = &retryPolicyUpdate
+		}
+		if cmd.Flags().Changed("parameters") {
+			var parameters map[string]any
+			if err := json.Unmarshal([]byte(updateJobOptions.Parameters), &parameters); err != nil {
+				return fmt.Errorf("parameters, %s, is not a valid JSON object", updateJobOptions.Parameters)
+			}
+
+			jobUpdate.Parameters = &parameters
+		}
+		if cmd.Flags().Changed("depends-on") {
+			jobUpdate.DependsOn = &updateJobOptions.DependsOn
+		}
 		if cmd.Flags().Changed("heartbeat-timeout") {
 			jobUpdate.HeartbeatTimeout = &updateJobOptions.HeartbeatTimeout
 		}
+		if cmd.Flags().Changed("max-restarts") {
+			jobUpdate.MaxRestarts = &updateJobOptions.MaxRestarts
+		}
+		if cmd.Flags().Changed("worker-pool") {
+			jobUpdate.WorkerPool = &updateJobOptions.WorkerPool
+		}
+		if cmd.Flags().Changed("worker-tags") {
+			jobUpdate.WorkerTags = &updateJobOptions.WorkerTags
+		}
+		if cmd.Flags().Changed("dispatch-mode") {
+			if !validators.ValidateDispatchMode(updateJobOptions.DispatchMode, true) {
+				return fmt.Errorf("dispatch mode, %s, must be push or lease", updateJobOptions.DispatchMode)
+			}
+
+			jobUpdate.DispatchMode = &updateJobOptions.DispatchMode
+		}
 
 		if cmd.Flags().Changed("next-run-at") {
 			nextRunAtTime, err := time.Parse(time.RFC3339, updateJobOptions.NextRunAt)
@@ -78,9 +169,27 @@ func init() {
 	updateJobCmd.Flags().BoolVarP(&updateJobOptions.Enabled, "enabled", "e", true, "Whether the job is enabled.")
 	updateJobCmd.Flags().StringVarP(&updateJobOptions.NextRunAt, "next-run-at", "r", "", "The next time the job should run.")
 	updateJobCmd.Flags().IntVarP(&updateJobOptions.Interval, "interval", "i", 0, "The interval to run the job in milliseconds.")
+	updateJobCmd.Flags().StringVar(&updateJobOptions.Schedule, "schedule", "", "The cron expression to run the job on, e.g. \"15 2 * * MON-FRI\". Set to \"\" to use the interval instead.")
+	updateJobCmd.Flags().StringVar(&updateJobOptions.TimeZone, "time-zone", "", "The IANA time zone to evaluate the schedule in, e.g. \"America/New_York\". Set to \"\" to use the server's time zone.")
 	updateJobCmd.Flags().IntVarP(&updateJobOptions.RunExecutionTimeout, "run-execution-timeout", "x", 0, "The time in milliseconds to wait for each run to complete.")
 	updateJobCmd.Flags().IntVarP(&updateJobOptions.RunStartTimeout, "run-start-timeout", "s", 0, "The time in milliseconds to wait for each run to start to start.")
+	updateJobCmd.Flags().StringVar(&updateJobOptions.ExecutionTimeoutAction, "execution-timeout-action", "", "What to do with runs that exceed the run execution timeout (cancel|fail|notify). Defaults to cancel.")
+	updateJobCmd.Flags().IntVar(&updateJobOptions.CancelGracePeriod, "cancel-grace-period", 0, "The time in milliseconds to wait for a cancelling run to be cancelled before it is forced to cancelled. If 0, the Scheduler's default is used.")
 	updateJobCmd.Flags().IntVarP(&updateJobOptions.MaxQueueCount, "max-queue-count", "q", 0, "The maximum number of runs that can be queued.")
 	updateJobCmd.Flags().BoolVarP(&updateJobOptions.AllowConcurrentRuns, "allow-concurrent-runs", "c", false, "Whether to allow concurrent runs of the job.")
+	updateJobCmd.Flags().StringVar(&updateJobOptions.ConcurrencyPolicy, "concurrency-policy", "", "What to do when a run is due while another is active and concurrent runs are not allowed (skip|queue). Defaults to skip.")
+	updateJobCmd.Flags().StringVar(&updateJobOptions.MisfirePolicy, "misfire-policy", "", "What to do with occurrences missed by more than the misfire threshold (fireAll|fireOnce|skip). Defaults to fireOnce.")
+	updateJobCmd.Flags().IntVar(&updateJobOptions.MisfireThreshold, "misfire-threshold", 0, "The time in milliseconds a run may start late before it is considered missed. Defaults to 60000.")
+	updateJobCmd.Flags().IntVar(&updateJobOptions.MaxAttempts, "max-attempts", 0, "The maximum number of attempts for each run, including the first. Runs are not retried if 1 or less.")
+	updateJobCmd.Flags().StringVar(&updateJobOptions.Backoff, "backoff", "", "How the delay between attempts grows (fixed|exponential). Defaults to fixed.")
+	updateJobCmd.Flags().IntVar(&updateJobOptions.RetryDelay, "retry-delay", 0, "The time in milliseconds to wait before the first retry.")
+	updateJobCmd.Flags().IntVar(&updateJobOptions.MaxRetryDelay, "max-retry-delay", 0, "The maximum time in milliseconds to wait between attempts. Unlimited if 0.")
+	updateJobCmd.Flags().BoolVar(&updateJobOptions.RetryOnTimeout, "retry-on-timeout", false, "Whether to retry runs cancelled because they timed out.")
+	updateJobCmd.Flags().StringVar(&updateJobOptions.Parameters, "parameters", "", "The default parameters of the job's runs as a JSON object. Replaces the existing parameters.")
+	updateJobCmd.Flags().StringSliceVar(&updateJobOptions.DependsOn, "depends-on", nil, "The names of the jobs that must complete before each run of the job. Replaces the existing dependencies.")
 	updateJobCmd.Flags().IntVarP(&updateJobOptions.HeartbeatTimeout, "heartbeat-timeout", "t", 0, "The time in milliseconds to wait for each heartbeat of a run.")
+	updateJobCmd.Flags().IntVar(&updateJobOptions.MaxRestarts, "max-restarts", 0, "The maximum number of times a run is restarted after a heartbeat timeout before it is failed. Defaults to 3.")
+	updateJobCmd.Flags().StringVar(&updateJobOptions.WorkerPool, "worker-pool", "", "The pool of workers that perform the job's runs. Set to \"\" to use the default pool.")
+	updateJobCmd.Flags().StringSliceVar(&updateJobOptions.WorkerTags, "worker-tags", nil, "The tags a worker must have to perform the job's runs. Replaces the existing tags.")
+	updateJobCmd.Flags().StringVar(&updateJobOptions.DispatchMode, "dispatch-mode", "", "How runs reach workers, published to the message bus or leased through the API (push|lease). Set to \"\" to use push.")
 }
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -85,14 +87,59 @@ func (svc RunService) Read(id string) (dtos.Run, error) {
 	return run, nil
 }
 
-func (svc RunService) Cancel(id string) error {
-	url := fmt.Sprintf("%s/runs/%s", svc.ApiUrl, id)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+func (svc RunService) Add(jobName string, runRequest dtos.RunRequest) (string, error) {
+	url := fmt.Sprintf("%s/jobs/%s/runs", svc.ApiUrl, jobName)
+	reqBody, err := json.Marshal(runRequest)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", svc.AccessToken))
+	req.Header.Set("Content-Type", "application/json")
+	client := http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", httpHelpers.ParseError(resp, "failed to run job")
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var addedRun dtos.Run
+	err = json.Unmarshal(body, &addedRun)
+	if err != nil {
+		return "", err
+	}
+
+	return addedRun.Id, nil
+}
+
+func (svc RunService) Cancel(id string, cancelRequest dtos.RunCancelRequest) error {
+	url := fmt.Sprintf("%s/runs/%s/cancel", svc.ApiUrl, id)
+	reqBody, err := json.Marshal(cancelRequest)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return err
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", svc.AccessToken))
+	req.Header.Set("Content-Type", "application/json")
 	client := http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -100,9 +147,78 @@ func (svc RunService) Cancel(id string) error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusNoContent {
 		return httpHelpers.ParseError(resp, "failed to cancel run")
 	}
 
 	return nil
 }
+
+func (svc RunService) Logs(id string) ([]dtos.RunLog, error) {
+	url := fmt.Sprintf("%s/runs/%s/logs", svc.ApiUrl, id)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", svc.AccessToken))
+	client := http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpHelpers.ParseError(resp, "failed to get logs")
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []dtos.RunLog
+	err = json.Unmarshal(body, &logs)
+	if err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// FollowLogs calls received with each log line of the run, including those
+// sent while following, until the run has finished.
+func (svc RunService) FollowLogs(id string, received func(runLog dtos.RunLog)) error {
+	url := fmt.Sprintf("%s/runs/%s/logs?follow=true", svc.ApiUrl, id)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", svc.AccessToken))
+	client := http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return httpHelpers.ParseError(resp, "failed to follow logs")
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var runLog dtos.RunLog
+		if err := decoder.Decode(&runLog); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		received(runLog)
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	httpHelpers "github.com/jacobmcgowan/simple-scheduler/services/cli/http-helpers"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+)
+
+type WorkerService struct {
+	ApiUrl      string
+	AccessToken string
+}
+
+func (svc WorkerService) Browse(filter dtos.WorkerFilter) ([]dtos.Worker, error) {
+	qb := httpHelpers.NewQueryBuilder()
+	qb.Add("jobName", filter.JobName)
+
+	url := fmt.Sprintf("%s/workers%s", svc.ApiUrl, qb.String())
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", svc.AccessToken))
+	client := http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpHelpers.ParseError(resp, "failed to get workers")
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var workers []dtos.Worker
+	err = json.Unmarshal(body, &workers)
+	if err != nil {
+		return nil, err
+	}
+
+	return workers, nil
+}
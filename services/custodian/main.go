@@ -65,9 +65,17 @@ func main() {
 
 	cust.Start(&wg)
 
+	workerCust := workers.WorkerCustodian{
+		WorkerRepo: dbResources.WorkerRepo,
+		Duration:   time.Duration(refreshInterval) * time.Second,
+	}
+
+	workerCust.Start(&wg)
+
 	log.Printf("Started %s", appName)
 
 	<-ctx.Done()
 	cust.Stop()
+	workerCust.Stop()
 	wg.Wait()
 }
@@ -0,0 +1,113 @@
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
+	repositoryErrors "github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories/errors"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+)
+
+// WorkerCustodian removes workers from the registry that have stopped sending
+// heartbeats without unregistering, such as when they crash.
+type WorkerCustodian struct {
+	WorkerRepo    repositories.WorkerRepository
+	Duration      time.Duration
+	quit          chan struct{}
+	isRunningLock sync.Mutex `default:"sync.Mutex{}"`
+	isRunning     bool
+	stopOnce      sync.Once
+}
+
+func (worker *WorkerCustodian) Start(wg *sync.WaitGroup) error {
+	worker.isRunningLock.Lock()
+	defer worker.isRunningLock.Unlock()
+
+	if worker.isRunning {
+		return nil
+	}
+
+	log.Printf("Starting worker custodian...")
+	worker.quit = make(chan struct{})
+	go worker.process(wg)
+	worker.isRunning = true
+
+	log.Printf("Started worker custodian")
+	return nil
+}
+
+func (worker *WorkerCustodian) removeExpiredWorkers() (int, error) {
+	now := time.Now()
+	filter := dtos.WorkerFilter{
+		ExpiredBefore: &now,
+	}
+	expiredWorkers, err := worker.WorkerRepo.Browse(filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get workers: %s", err)
+	}
+
+	count := 0
+	errs := []error{}
+	for _, expiredWorker := range expiredWorkers {
+		if err := worker.WorkerRepo.Delete(expiredWorker.Id); err != nil {
+			// Another custodian may have removed the worker already
+			var notFoundErr *repositoryErrors.NotFoundError
+			if !errors.As(err, &notFoundErr) {
+				errs = append(errs, err)
+			}
+
+			continue
+		}
+
+		count++
+	}
+
+	return count, errors.Join(errs...)
+}
+
+func (worker *WorkerCustodian) Stop() {
+	worker.stopOnce.Do(func() {
+		worker.isRunningLock.Lock()
+		defer worker.isRunningLock.Unlock()
+
+		if !worker.isRunning {
+			return
+		}
+
+		log.Printf("Stopping worker custodian...")
+		close(worker.quit)
+	})
+}
+
+func (worker *WorkerCustodian) stopped() {
+	worker.isRunningLock.Lock()
+	defer worker.isRunningLock.Unlock()
+	worker.isRunning = false
+}
+
+func (worker *WorkerCustodian) process(wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	ticker := time.NewTicker(worker.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-worker.quit:
+			log.Printf("Stopped worker custodian")
+			worker.stopped()
+			return
+		case <-ticker.C:
+			if count, err := worker.removeExpiredWorkers(); err != nil {
+				log.Printf("Failed to remove expired workers: %s", err)
+			} else {
+				log.Printf("Removed %d expired workers", count)
+			}
+		}
+	}
+}
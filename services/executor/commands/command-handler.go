@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/worker"
+)
+
+const (
+	InvalidCommandCode = "INVALID_COMMAND"
+	StartFailedCode    = "START_FAILED"
+	TimeoutCode        = "TIMEOUT"
+	NonZeroExitCode    = "NON_ZERO_EXIT"
+)
+
+// waitDelay is how long to wait for the output of a killed command to close
+// before giving up on it.
+const waitDelay = time.Second * 5
+
+// CommandHandler runs the command in the parameters of each run. Output is
+// published to the run's log, stdout as info and stderr as error lines. When
+// the run is cancelled or times out, the command's process group is killed.
+type CommandHandler struct {
+	// Shell is used to run commands given as a string. Defaults to /bin/sh.
+	Shell string
+}
+
+func (handler CommandHandler) Run(ctx context.Context, run *worker.Run) error {
+	command, err := ParseCommand(run.Parameters, handler.Shell)
+	if err != nil {
+		return &worker.RunError{
+			Err:  fmt.Errorf("invalid command: %w", err),
+			Code: InvalidCommandCode,
+		}
+	}
+
+	cmdCtx := ctx
+	if command.Timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(ctx, command.Timeout)
+		defer cancel()
+	}
+
+	env, err := commandEnv(run, command)
+	if err != nil {
+		return &worker.RunError{
+			Err:  err,
+			Code: InvalidCommandCode,
+		}
+	}
+
+	stdout := &logWriter{run: run, level: "info"}
+	stderr := &logWriter{run: run, level: "error"}
+	cmd := exec.CommandContext(cmdCtx, command.Args[0], command.Args[1:]...)
+	cmd.Dir = command.WorkingDir
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = waitDelay
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return &worker.RunError{
+			Err:  fmt.Errorf("failed to start command: %w", err),
+			Code: StartFailedCode,
+		}
+	}
+
+	err = cmd.Wait()
+	stdout.Flush()
+	stderr.Flush()
+
+	exitCode := cmd.ProcessState.ExitCode()
+	if exitCode >= 0 {
+		run.SetExitCode(exitCode)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if errors.Is(cmdCtx.Err(), context.DeadlineExceeded) {
+		return &worker.RunError{
+			Err:  fmt.Errorf("command timed out after %s", command.Timeout),
+			Code: TimeoutCode,
+		}
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitCode > 0 {
+		return &worker.RunError{
+			Err:  fmt.Errorf("command exited with code %d", exitCode),
+			Code: NonZeroExitCode,
+		}
+	}
+
+	return err
+}
+
+// commandEnv returns the environment of a command. Only PATH and HOME are
+// passed on from the executor so that its settings are not exposed to the
+// command, along with details of the run and the command's own environment.
+func commandEnv(run *worker.Run, command Command) ([]string, error) {
+	parameters, err := json.Marshal(run.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize parameters: %w", err)
+	}
+
+	env := []string{}
+	for _, name := range []string{"PATH", "HOME"} {
+		if value, found := os.LookupEnv(name); found {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	env = append(env,
+		"SIMPLE_SCHEDULER_JOB_NAME="+run.JobName,
+		"SIMPLE_SCHEDULER_RUN_ID="+run.Id,
+		"SIMPLE_SCHEDULER_RUN_ATTEMPT="+strconv.Itoa(run.Attempt),
+		"SIMPLE_SCHEDULER_RUN_PARAMETERS="+string(parameters),
+	)
+	for name, value := range command.Env {
+		env = append(env, name+"="+value)
+	}
+
+	return env, nil
+}
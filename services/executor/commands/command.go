@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/executorParameters"
+)
+
+const defaultShell = "/bin/sh"
+
+// Command is a command to run for a run, read from the run's parameters.
+type Command struct {
+	Args       []string
+	Env        map[string]string
+	WorkingDir string
+	Timeout    time.Duration
+}
+
+// ParseCommand reads a command from the parameters of a run. The command is
+// either a string, which is run with the shell, or an array of arguments,
+// which is run without one. The environment, working directory and timeout in
+// milliseconds are optional.
+func ParseCommand(parameters map[string]any, shell string) (Command, error) {
+	if shell == "" {
+		shell = defaultShell
+	}
+
+	command := Command{}
+	switch value := parameters[executorParameters.Command].(type) {
+	case nil:
+		return command, errors.New("command is required")
+	case string:
+		if value == "" {
+			return command, errors.New("command is required")
+		}
+
+		command.Args = []string{shell, "-c", value}
+	case []any:
+		if len(value) == 0 {
+			return command, errors.New("command is required")
+		}
+
+		for i, arg := range value {
+			str, ok := arg.(string)
+			if !ok {
+				return command, fmt.Errorf("command argument %d must be a string", i)
+			}
+
+			command.Args = append(command.Args, str)
+		}
+	default:
+		return command, errors.New("command must be a string or an array of strings")
+	}
+
+	switch value := parameters[executorParameters.Env].(type) {
+	case nil:
+	case map[string]any:
+		command.Env = map[string]string{}
+		for name, envValue := range value {
+			str, ok := envValue.(string)
+			if !ok {
+				return command, fmt.Errorf("env %s must be a string", name)
+			}
+
+			command.Env[name] = str
+		}
+	default:
+		return command, errors.New("env must be an object")
+	}
+
+	switch value := parameters[executorParameters.WorkingDir].(type) {
+	case nil:
+	case string:
+		command.WorkingDir = value
+	default:
+		return command, errors.New("workingDir must be a string")
+	}
+
+	switch value := parameters[executorParameters.Timeout].(type) {
+	case nil:
+	case float64:
+		if value < 0 {
+			return command, errors.New("timeout must not be negative")
+		}
+
+		command.Timeout = time.Duration(value * float64(time.Millisecond))
+	default:
+		return command, errors.New("timeout must be a number")
+	}
+
+	return command, nil
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"bytes"
+	"log"
+	"sync"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/worker"
+)
+
+const maxLogLineLength = 64 * 1024
+
+// logWriter publishes each line written to it to the log of a run.
+type logWriter struct {
+	run   *worker.Run
+	level string
+	lock  sync.Mutex `default:"sync.Mutex{}"`
+	buf   []byte
+}
+
+func (writer *logWriter) Write(p []byte) (int, error) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	writer.buf = append(writer.buf, p...)
+	for {
+		i := bytes.IndexByte(writer.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		writer.publish(writer.buf[:i])
+		writer.buf = writer.buf[i+1:]
+	}
+
+	if len(writer.buf) >= maxLogLineLength {
+		writer.publish(writer.buf)
+		writer.buf = nil
+	}
+
+	// A log line that fails to publish must not stop the command
+	return len(p), nil
+}
+
+// Flush publishes the last line if it did not end with a new line.
+func (writer *logWriter) Flush() {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	if len(writer.buf) > 0 {
+		writer.publish(writer.buf)
+		writer.buf = nil
+	}
+}
+
+func (writer *logWriter) publish(line []byte) {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if err := writer.run.Log(writer.level, string(line)); err != nil {
+		log.Printf("Failed to publish output of run %s: %s", writer.run.Id, err)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/services/executor/commands"
+	"github.com/jacobmcgowan/simple-scheduler/services/executor/modes"
+	"github.com/jacobmcgowan/simple-scheduler/services/executor/webhooks"
+	messageBusTypes "github.com/jacobmcgowan/simple-scheduler/shared/message-bus/message-bus-types"
+	"github.com/jacobmcgowan/simple-scheduler/shared/resources"
+	envVars "github.com/jacobmcgowan/simple-scheduler/shared/resources/env-vars"
+	"github.com/jacobmcgowan/simple-scheduler/shared/validators"
+	"github.com/jacobmcgowan/simple-scheduler/shared/worker"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	appName := "simple-scheduler-executor"
+
+	log.Printf("Starting %s...", appName)
+
+	envFilename := ".env"
+	if len(os.Args) > 1 {
+		envFilename = os.Args[1]
+	}
+
+	if err := godotenv.Load(envFilename); err != nil {
+		log.Fatalf("Failed to load environment file, %s", envFilename)
+	}
+
+	jobNames := []string{}
+	for _, jobName := range strings.Split(os.Getenv(envVars.ExecutorJobs), ",") {
+		if jobName = strings.TrimSpace(jobName); jobName != "" {
+			jobNames = append(jobNames, jobName)
+		}
+	}
+
+	if len(jobNames) == 0 {
+		log.Fatalf("No jobs set in %s", envVars.ExecutorJobs)
+	}
+
+	hrtbtInterval, err := strconv.Atoi(os.Getenv(envVars.HeartbeatInterval))
+	if err != nil || hrtbtInterval < 1 {
+		log.Fatalf("Heartbeat interval invalid")
+	}
+
+	maxConcurrentRuns, err := strconv.Atoi(os.Getenv(envVars.ExecutorMaxConcurrentRuns))
+	if err != nil || maxConcurrentRuns < 1 {
+		log.Fatalf("Max concurrent runs invalid")
+	}
+
+	shutdownTimeout, err := strconv.Atoi(os.Getenv(envVars.ExecutorShutdownTimeout))
+	if err != nil || shutdownTimeout < 1 {
+		log.Fatalf("Shutdown timeout invalid")
+	}
+
+	// All modes are enabled and the handlers' defaults are used if these are
+	// not set
+	enabledModes := []modes.ExecutorMode{modes.Command, modes.Http}
+	if modesStr := os.Getenv(envVars.ExecutorModes); modesStr != "" {
+		enabledModes = []modes.ExecutorMode{}
+		for _, mode := range strings.Split(modesStr, ",") {
+			switch mode := modes.ExecutorMode(strings.TrimSpace(mode)); mode {
+			case modes.Command, modes.Http:
+				enabledModes = append(enabledModes, mode)
+			default:
+				log.Fatalf("Executor mode %s invalid", mode)
+			}
+		}
+	}
+
+	maxResponseLength := 0
+	if maxResponseLengthStr := os.Getenv(envVars.ExecutorMaxResponseLength); maxResponseLengthStr != "" {
+		maxResponseLength, err = strconv.Atoi(maxResponseLengthStr)
+		if err != nil || maxResponseLength < 1 {
+			log.Fatalf("Max response length invalid")
+		}
+	}
+
+	pool := strings.TrimSpace(os.Getenv(envVars.ExecutorPool))
+	if !validators.ValidateWorkerPool(pool, true) {
+		log.Fatalf("Worker pool invalid")
+	}
+
+	tags := []string{}
+	for _, tag := range strings.Split(os.Getenv(envVars.ExecutorTags), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	if !validators.ValidateWorkerTags(tags) {
+		log.Fatalf("Worker tags invalid")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	msgBusEnv := resources.LoadMessageBusEnv()
+	if msgBusEnv.Type == string(messageBusTypes.Webhook) {
+		log.Fatalf("The executor cannot use the %s message bus, it only delivers actions to callback URLs", msgBusEnv.Type)
+	}
+
+	msgBusResources, err := resources.RegisterMessageBus(msgBusEnv)
+	if err != nil {
+		log.Fatalf("Failed to register message bus: %s", err)
+	}
+
+	log.Printf("Connecting to message bus %s...", msgBusResources.Name)
+	if err = msgBusResources.MessageBus.Connect(); err != nil {
+		log.Fatalf("Failed to connect to message bus: %s", err)
+	}
+	defer msgBusResources.MessageBus.Close()
+	log.Println("Connected to message bus")
+
+	handler := modes.ModeHandler{
+		Handlers: map[modes.ExecutorMode]worker.Handler{},
+	}
+	for _, mode := range enabledModes {
+		switch mode {
+		case modes.Command:
+			handler.Handlers[mode] = commands.CommandHandler{
+				Shell: os.Getenv(envVars.ExecutorShell),
+			}
+		case modes.Http:
+			handler.Handlers[mode] = webhooks.WebhookHandler{
+				MaxResponseBodyLength: maxResponseLength,
+			}
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	executors := []*worker.Worker{}
+	for _, jobName := range jobNames {
+		executor := &worker.Worker{
+			JobName:           jobName,
+			MessageBus:        msgBusResources.MessageBus,
+			Handler:           handler,
+			Pool:              pool,
+			Tags:              tags,
+			HeartbeatDuration: time.Duration(int(time.Millisecond) * hrtbtInterval),
+			MaxConcurrentRuns: maxConcurrentRuns,
+			ShutdownTimeout:   time.Duration(int(time.Millisecond) * shutdownTimeout),
+		}
+		if err := executor.Start(&wg); err != nil {
+			log.Fatalf("Failed to start executor for job %s: %s", jobName, err)
+		}
+
+		executors = append(executors, executor)
+	}
+
+	log.Printf("Started %s", appName)
+
+	<-ctx.Done()
+
+	// Executors are stopped at the same time so that shutting down takes at
+	// most one shutdown timeout
+	stopWg := sync.WaitGroup{}
+	for _, executor := range executors {
+		stopWg.Add(1)
+		go func() {
+			defer stopWg.Done()
+			executor.Stop()
+		}()
+	}
+
+	stopWg.Wait()
+	wg.Wait()
+}
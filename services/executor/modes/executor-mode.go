@@ -0,0 +1,8 @@
+package modes
+
+type ExecutorMode string
+
+const (
+	Command ExecutorMode = "command"
+	Http    ExecutorMode = "http"
+)
@@ -0,0 +1,39 @@
+package modes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/executorParameters"
+	"github.com/jacobmcgowan/simple-scheduler/shared/worker"
+)
+
+const UnsupportedModeCode = "UNSUPPORTED_MODE"
+
+// ModeHandler performs each run with the handler of its mode. Runs with an
+// http object in their parameters are http runs, all others are command runs.
+type ModeHandler struct {
+	Handlers map[ExecutorMode]worker.Handler
+}
+
+func (handler ModeHandler) Run(ctx context.Context, run *worker.Run) error {
+	mode := RunMode(run.Parameters)
+	modeHandler, found := handler.Handlers[mode]
+	if !found {
+		return &worker.RunError{
+			Err:  fmt.Errorf("%s runs are not enabled", mode),
+			Code: UnsupportedModeCode,
+		}
+	}
+
+	return modeHandler.Run(ctx, run)
+}
+
+// RunMode returns the mode of a run from its parameters.
+func RunMode(parameters map[string]any) ExecutorMode {
+	if _, found := parameters[executorParameters.Http]; found {
+		return Http
+	}
+
+	return Command
+}
@@ -0,0 +1,168 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/executorParameters"
+)
+
+// Request is an HTTP request to perform for a run, read from the run's
+// parameters.
+type Request struct {
+	Method              string
+	Url                 string
+	Headers             map[string]string
+	BodyTemplate        *template.Template
+	Body                []byte
+	ExpectedStatusCodes []int
+	Timeout             time.Duration
+}
+
+// ParseRequest reads a request from the http object in the parameters of a run.
+// The method defaults to GET, or POST if there is a body. A body that is a
+// string is a template executed with the run, a body that is an object or array
+// is sent as JSON. Any 2xx status is expected if no status codes are given.
+func ParseRequest(parameters map[string]any) (Request, error) {
+	request := Request{}
+	params, ok := parameters[executorParameters.Http].(map[string]any)
+	if !ok {
+		return request, errors.New("http must be an object")
+	}
+
+	rawUrl, ok := params["url"].(string)
+	if !ok || rawUrl == "" {
+		return request, errors.New("url is required")
+	}
+
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+		return request, fmt.Errorf("url %s must be an absolute http or https URL", rawUrl)
+	}
+
+	request.Url = rawUrl
+
+	switch value := params["body"].(type) {
+	case nil:
+	case string:
+		tmpl, err := template.New("body").
+			Option("missingkey=error").
+			Funcs(templateFuncs).
+			Parse(value)
+		if err != nil {
+			return request, fmt.Errorf("invalid body template: %w", err)
+		}
+
+		request.BodyTemplate = tmpl
+	case map[string]any, []any:
+		body, err := json.Marshal(value)
+		if err != nil {
+			return request, fmt.Errorf("invalid body: %w", err)
+		}
+
+		request.Body = body
+	default:
+		return request, errors.New("body must be a string or a JSON object")
+	}
+
+	switch value := params["method"].(type) {
+	case nil:
+		request.Method = http.MethodGet
+		if request.hasBody() {
+			request.Method = http.MethodPost
+		}
+	case string:
+		request.Method = strings.ToUpper(value)
+	default:
+		return request, errors.New("method must be a string")
+	}
+
+	switch value := params["headers"].(type) {
+	case nil:
+	case map[string]any:
+		request.Headers = map[string]string{}
+		for name, headerValue := range value {
+			str, ok := headerValue.(string)
+			if !ok {
+				return request, fmt.Errorf("header %s must be a string", name)
+			}
+
+			request.Headers[name] = str
+		}
+	default:
+		return request, errors.New("headers must be an object")
+	}
+
+	if request.Body != nil && !request.hasHeader("Content-Type") {
+		if request.Headers == nil {
+			request.Headers = map[string]string{}
+		}
+
+		request.Headers["Content-Type"] = "application/json"
+	}
+
+	switch value := params["expectedStatusCodes"].(type) {
+	case nil:
+	case []any:
+		for _, code := range value {
+			num, ok := code.(float64)
+			if !ok || num < 100 || num > 599 || num != float64(int(num)) {
+				return request, fmt.Errorf("expected status code %v must be a number between 100 and 599", code)
+			}
+
+			request.ExpectedStatusCodes = append(request.ExpectedStatusCodes, int(num))
+		}
+	default:
+		return request, errors.New("expectedStatusCodes must be an array")
+	}
+
+	switch value := params["timeout"].(type) {
+	case nil:
+	case float64:
+		if value < 0 {
+			return request, errors.New("timeout must not be negative")
+		}
+
+		request.Timeout = time.Duration(value * float64(time.Millisecond))
+	default:
+		return request, errors.New("timeout must be a number")
+	}
+
+	return request, nil
+}
+
+// IsExpectedStatus returns whether a response status code means the run
+// succeeded.
+func (request Request) IsExpectedStatus(statusCode int) bool {
+	if len(request.ExpectedStatusCodes) == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+
+	for _, code := range request.ExpectedStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (request Request) hasBody() bool {
+	return request.BodyTemplate != nil || request.Body != nil
+}
+
+func (request Request) hasHeader(name string) bool {
+	for header := range request.Headers {
+		if http.CanonicalHeaderKey(header) == http.CanonicalHeaderKey(name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,23 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"text/template"
+)
+
+// templateFuncs are the functions available to body templates. json allows
+// values to be inserted into JSON bodies with quoting and escaping.
+var templateFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		body, err := json.Marshal(value)
+		return string(body), err
+	},
+}
+
+// templateData is the data body templates are executed with.
+type templateData struct {
+	JobName    string
+	RunId      string
+	Attempt    int
+	Parameters map[string]any
+}
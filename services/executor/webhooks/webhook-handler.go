@@ -0,0 +1,174 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/worker"
+)
+
+const (
+	InvalidRequestCode   = "INVALID_REQUEST"
+	RequestFailedCode    = "REQUEST_FAILED"
+	TimeoutCode          = "TIMEOUT"
+	UnexpectedStatusCode = "UNEXPECTED_STATUS"
+)
+
+const defaultMaxResponseBodyLength = 4096
+
+// WebhookHandler performs the HTTP request in the parameters of each run. The
+// response's status code and body, truncated to MaxResponseBodyLength bytes,
+// are stored as the run's result. The request is aborted when the run is
+// cancelled.
+type WebhookHandler struct {
+	Client *http.Client
+	// MaxResponseBodyLength is the number of bytes of the response body kept
+	// in the run's result. Defaults to 4096.
+	MaxResponseBodyLength int
+}
+
+func (handler WebhookHandler) Run(ctx context.Context, run *worker.Run) error {
+	request, err := ParseRequest(run.Parameters)
+	if err != nil {
+		return &worker.RunError{
+			Err:  fmt.Errorf("invalid request: %w", err),
+			Code: InvalidRequestCode,
+		}
+	}
+
+	body, err := handler.body(request, run)
+	if err != nil {
+		return &worker.RunError{
+			Err:  err,
+			Code: InvalidRequestCode,
+		}
+	}
+
+	reqCtx := ctx
+	if request.Timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, request.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, request.Method, request.Url, body)
+	if err != nil {
+		return &worker.RunError{
+			Err:  fmt.Errorf("invalid request: %w", err),
+			Code: InvalidRequestCode,
+		}
+	}
+
+	for name, value := range request.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := handler.client().Do(req)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+		return &worker.RunError{
+			Err:  fmt.Errorf("request timed out after %s", request.Timeout),
+			Code: TimeoutCode,
+		}
+	}
+
+	if err != nil {
+		return &worker.RunError{
+			Err:  fmt.Errorf("request failed: %w", err),
+			Code: RequestFailedCode,
+		}
+	}
+
+	respBody, truncated, err := handler.readBody(resp)
+	if err != nil {
+		return &worker.RunError{
+			Err:  fmt.Errorf("failed to read response: %w", err),
+			Code: RequestFailedCode,
+		}
+	}
+
+	run.SetResult(map[string]any{
+		"statusCode": resp.StatusCode,
+		"body":       respBody,
+		"truncated":  truncated,
+	})
+	run.Logf("info", "%s %s returned %s", request.Method, request.Url, resp.Status)
+
+	if !request.IsExpectedStatus(resp.StatusCode) {
+		return &worker.RunError{
+			Err:  fmt.Errorf("request returned unexpected status %s", resp.Status),
+			Code: UnexpectedStatusCode,
+		}
+	}
+
+	return nil
+}
+
+func (handler WebhookHandler) body(request Request, run *worker.Run) (io.Reader, error) {
+	if request.BodyTemplate != nil {
+		data := templateData{
+			JobName:    run.JobName,
+			RunId:      run.Id,
+			Attempt:    run.Attempt,
+			Parameters: run.Parameters,
+		}
+		buf := bytes.Buffer{}
+		if err := request.BodyTemplate.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to execute body template: %w", err)
+		}
+
+		return &buf, nil
+	}
+
+	if request.Body != nil {
+		return bytes.NewReader(request.Body), nil
+	}
+
+	return nil, nil
+}
+
+// readBody reads up to MaxResponseBodyLength bytes of the response body and
+// whether there was more.
+func (handler WebhookHandler) readBody(resp *http.Response) (string, bool, error) {
+	maxLength := handler.MaxResponseBodyLength
+	if maxLength <= 0 {
+		maxLength = defaultMaxResponseBodyLength
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxLength)+1))
+	if err != nil {
+		return "", false, err
+	}
+
+	truncated := len(body) > maxLength
+	if truncated {
+		body = body[:maxLength]
+
+		// Avoid cutting a character in half
+		for i := 0; i < utf8.UTFMax-1 && len(body) > 0 && !utf8.Valid(body); i++ {
+			body = body[:len(body)-1]
+		}
+	}
+
+	return string(body), truncated, nil
+}
+
+func (handler WebhookHandler) client() *http.Client {
+	if handler.Client == nil {
+		return http.DefaultClient
+	}
+
+	return handler.Client
+}
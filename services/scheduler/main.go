@@ -9,6 +9,7 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/jacobmcgowan/simple-scheduler/services/scheduler/workers"
 	"github.com/jacobmcgowan/simple-scheduler/shared/resources"
@@ -56,6 +57,42 @@ func main() {
 		log.Fatalf("Heartbeat interval invalid")
 	}
 
+	requestPollInterval, err := strconv.Atoi(os.Getenv(envVars.RequestPollInterval))
+	if err != nil || requestPollInterval < 1 {
+		log.Fatalf("Request poll interval invalid")
+	}
+
+	defaultTimeZone, err := time.LoadLocation(os.Getenv(envVars.DefaultTimeZone))
+	if err != nil {
+		log.Fatalf("Default time zone invalid: %s", err)
+	}
+
+	// The run log settings are optional and use the Scheduler's defaults if
+	// they are not set
+	runLogMaxLines := 0
+	if runLogMaxLinesStr := os.Getenv(envVars.RunLogMaxLines); runLogMaxLinesStr != "" {
+		runLogMaxLines, err = strconv.Atoi(runLogMaxLinesStr)
+		if err != nil || runLogMaxLines < 1 {
+			log.Fatalf("Run log max lines invalid")
+		}
+	}
+
+	runLogRetention := 0
+	if runLogRetentionStr := os.Getenv(envVars.RunLogRetention); runLogRetentionStr != "" {
+		runLogRetention, err = strconv.Atoi(runLogRetentionStr)
+		if err != nil || runLogRetention < 1 {
+			log.Fatalf("Run log retention invalid")
+		}
+	}
+
+	cancelGracePeriod := 0
+	if cancelGracePeriodStr := os.Getenv(envVars.CancelGracePeriod); cancelGracePeriodStr != "" {
+		cancelGracePeriod, err = strconv.Atoi(cancelGracePeriodStr)
+		if err != nil || cancelGracePeriod < 1 {
+			log.Fatalf("Cancel grace period invalid")
+		}
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
@@ -93,16 +130,31 @@ func main() {
 		ManagerRepo:          dbResources.ManagerRepo,
 		JobRepo:              dbResources.JobRepo,
 		RunRepo:              dbResources.RunRepo,
+		RunLogRepo:           dbResources.RunLogRepo,
 		CacheRefreshDuration: time.Duration(int(time.Millisecond) * refreshInterval),
 		CleanupDuration:      time.Duration(int(time.Millisecond) * cleanupInterval),
 		HeartbeatDuration:    time.Duration(int(time.Millisecond) * hrtbtInterval),
+		RequestPollDuration:  time.Duration(int(time.Millisecond) * requestPollInterval),
+		DefaultTimeZone:      defaultTimeZone,
+		MaxRunLogLines:       runLogMaxLines,
+		RunLogRetention:      time.Duration(int(time.Hour) * runLogRetention),
+		CancelGracePeriod:    time.Duration(int(time.Millisecond) * cancelGracePeriod),
 	}
 
 	manager.Start(&wg)
 
+	registry := workers.WorkerRegistry{
+		MessageBus: msgBusResources.MessageBus,
+		WorkerRepo: dbResources.WorkerRepo,
+	}
+	if err := registry.Start(&wg); err != nil {
+		log.Fatalf("Failed to start worker registry: %s", err)
+	}
+
 	log.Printf("Started %s", appName)
 
 	<-ctx.Done()
+	registry.Stop()
 	manager.Stop()
 	wg.Wait()
 }
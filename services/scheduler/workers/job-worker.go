@@ -2,29 +2,81 @@ package workers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/jacobmcgowan/simple-scheduler/shared/backoffStrategies"
+	"github.com/jacobmcgowan/simple-scheduler/shared/concurrencyPolicies"
 	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
+	repositoryErrors "github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories/errors"
 	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/jobActions"
 	messageBus "github.com/jacobmcgowan/simple-scheduler/shared/message-bus"
+	"github.com/jacobmcgowan/simple-scheduler/shared/misfirePolicies"
+	"github.com/jacobmcgowan/simple-scheduler/shared/outbox"
 	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/schedules"
+	"github.com/jacobmcgowan/simple-scheduler/shared/validators"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workerPools"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workflows"
 )
 
+const defaultMisfireThreshold = time.Minute
+
+// Bounds the runs started at once for the fireAll misfire policy.
+const maxMisfiredRuns = 100
+
+const defaultRequestPollDuration = time.Second
+
+const defaultMaxRunLogLines = 10000
+
+// jobUpdate is a job definition read from the database and the time the read
+// started.
+type jobUpdate struct {
+	job    dtos.Job
+	readAt time.Time
+}
+
+// JobWorker schedules the runs of a job and handles the messages of its
+// workers. Job is read by the message consumers and retry timers as well as
+// the worker's own goroutine, so once started it is only replaced through
+// Update and read through job.
 type JobWorker struct {
-	Job            dtos.Job
-	MessageBus     messageBus.MessageBus
-	JobRepo        repositories.JobRepository
-	RunRepo        repositories.RunRepository
-	quit           chan struct{}
-	isRunningLock  sync.Mutex `default:"sync.Mutex{}"`
-	isRunning      bool
-	actionQueue    string
-	statusQueue    string
-	heartbeatQueue string
-	stopOnce       sync.Once
+	Job                 dtos.Job
+	MessageBus          messageBus.MessageBus
+	JobRepo             repositories.JobRepository
+	RunRepo             repositories.RunRepository
+	RunLogRepo          repositories.RunLogRepository
+	Dispatcher          *outbox.Dispatcher
+	DefaultTimeZone     *time.Location
+	RequestPollDuration time.Duration
+	MaxRunLogLines      int
+	quit                chan struct{}
+	isRunningLock       sync.Mutex `default:"sync.Mutex{}"`
+	isRunning           bool
+	actionQueue         string
+	statusQueue         string
+	heartbeatQueue      string
+	deadLetterQueue     string
+	logQueue            string
+	rejectedMessages    atomic.Int64
+	ignoredMessages     atomic.Int64
+	stopOnce            sync.Once
+	runsLock            sync.Mutex `default:"sync.Mutex{}"`
+	retryTimersLock     sync.Mutex `default:"sync.Mutex{}"`
+	retryTimers         map[string]*time.Timer
+	jobUpdates          chan jobUpdate
+	jobLock             sync.RWMutex `default:"sync.RWMutex{}"`
+	jobEditedAt         time.Time
+	logCountsLock       sync.Mutex `default:"sync.Mutex{}"`
+	logCounts           map[string]int64
 }
 
 func (worker *JobWorker) Start(wg *sync.WaitGroup) error {
@@ -35,21 +87,29 @@ func (worker *JobWorker) Start(wg *sync.WaitGroup) error {
 		return nil
 	}
 
-	log.Printf("Starting job %s...", worker.Job.Name)
-	fullName := "scheduler.job." + worker.Job.Name
+	log.Printf("Starting job %s...", worker.job().Name)
+	fullName := "scheduler.job." + worker.job().Name
 	worker.actionQueue = fullName + ".action"
 	worker.statusQueue = fullName + ".status"
 	worker.heartbeatQueue = fullName + ".heartbeat"
+	worker.deadLetterQueue = fullName + ".deadletter"
+	worker.logQueue = fullName + ".log"
 	err := worker.MessageBus.Register(
 		fullName,
 		map[string][]string{
-			worker.actionQueue:    {"action"},
-			worker.statusQueue:    {"status"},
-			worker.heartbeatQueue: {"heartbeat"},
+			worker.actionQueue:     {"action"},
+			worker.statusQueue:     {"status"},
+			worker.heartbeatQueue:  {"heartbeat"},
+			worker.deadLetterQueue: {"deadletter"},
+			worker.logQueue:        {"log"},
 		},
 	)
 	if err != nil {
-		return fmt.Errorf("failed to register job %s to message bus: %s", worker.Job.Name, err)
+		return fmt.Errorf("failed to register job %s to message bus: %s", worker.job().Name, err)
+	}
+
+	if err = worker.registerPoolQueue(); err != nil {
+		return err
 	}
 
 	err = worker.MessageBus.Subscribe(
@@ -58,7 +118,7 @@ func (worker *JobWorker) Start(wg *sync.WaitGroup) error {
 		worker.statusMessageReceived,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to subscribe to status queue for job %s: %s", worker.Job.Name, err)
+		return fmt.Errorf("failed to subscribe to status queue for job %s: %s", worker.job().Name, err)
 	}
 
 	err = worker.MessageBus.Subscribe(
@@ -67,26 +127,83 @@ func (worker *JobWorker) Start(wg *sync.WaitGroup) error {
 		worker.heartbeatMessageReceived,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to subscribe to heartbeat queue for job %s: %s", worker.Job.Name, err)
+		return fmt.Errorf("failed to subscribe to heartbeat queue for job %s: %s", worker.job().Name, err)
+	}
+
+	err = worker.MessageBus.Subscribe(
+		wg,
+		worker.logQueue,
+		worker.logMessageReceived,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to log queue for job %s: %s", worker.job().Name, err)
 	}
 
 	worker.quit = make(chan struct{})
+	worker.jobUpdates = make(chan jobUpdate, 1)
 	go worker.process(wg)
 	worker.isRunning = true
 
-	log.Printf("Started job %s", worker.Job.Name)
+	log.Printf("Started job %s", worker.job().Name)
 	return nil
 }
 
 func (worker *JobWorker) Stop() {
 	worker.stopOnce.Do(func() {
-		log.Printf("Stopping job %s...", worker.Job.Name)
+		log.Printf("Stopping job %s...", worker.job().Name)
 		worker.MessageBus.Unsubscribe(worker.statusQueue)
 		worker.MessageBus.Unsubscribe(worker.heartbeatQueue)
+		worker.MessageBus.Unsubscribe(worker.logQueue)
+		worker.stopRetryTimers()
 		close(worker.quit)
 	})
 }
 
+// job returns a copy of the job definition that is safe to read from any
+// goroutine.
+func (worker *JobWorker) job() dtos.Job {
+	worker.jobLock.RLock()
+	defer worker.jobLock.RUnlock()
+	return worker.Job
+}
+
+// Update replaces the job definition, read from the database at the given
+// time, rescheduling the next run of a running worker if needed. Only the
+// latest update is kept if the worker has not yet applied the previous one.
+// Workers that are not running use the definition once started.
+func (worker *JobWorker) Update(job dtos.Job, readAt time.Time) {
+	worker.isRunningLock.Lock()
+	defer worker.isRunningLock.Unlock()
+
+	if !worker.isRunning {
+		worker.jobLock.Lock()
+		worker.Job = job
+		worker.jobLock.Unlock()
+		return
+	}
+
+	select {
+	case <-worker.jobUpdates:
+	default:
+	}
+
+	select {
+	case worker.jobUpdates <- jobUpdate{job: job, readAt: readAt}:
+	default:
+	}
+}
+
+func (worker *JobWorker) stopRetryTimers() {
+	worker.retryTimersLock.Lock()
+	defer worker.retryTimersLock.Unlock()
+
+	for _, timer := range worker.retryTimers {
+		timer.Stop()
+	}
+
+	worker.retryTimers = nil
+}
+
 func (worker *JobWorker) stopped() {
 	worker.isRunningLock.Lock()
 	defer worker.isRunningLock.Unlock()
@@ -94,165 +211,1068 @@ func (worker *JobWorker) stopped() {
 }
 
 func (worker *JobWorker) statusMessageReceived(body []byte) (error, bool) {
-	log.Printf("Job %s status message received: %s", worker.Job.Name, body)
+	log.Printf("Job %s status message received: %s", worker.job().Name, body)
 	var msg dtos.JobStatusMessage
 	if err := json.Unmarshal(body, &msg); err != nil {
-		return fmt.Errorf("failed to deserialize status message for job %s: %s", worker.Job.Name, err), false
+		return worker.rejectStatusMessage(body, fmt.Errorf("failed to deserialize status message for job %s: %s", worker.job().Name, err))
 	}
 
 	status := runStatuses.RunStatus(msg.Status)
-	switch status {
-	case runStatuses.Cancelled,
-		runStatuses.Cancelling,
-		runStatuses.Completed,
-		runStatuses.Failed,
-		runStatuses.Pending,
-		runStatuses.Running:
-		if err := worker.updateRunStatus(msg.RunId, status); err != nil {
-			return fmt.Errorf("failed to update run %s status to %s: %s", msg.RunId, status, err), true
+	if !runStatuses.IsClientStatus(status) {
+		return worker.rejectStatusMessage(body, fmt.Errorf("unsupported status %s for job %s", status, worker.job().Name))
+	}
+
+	if !validators.ValidateProgress(msg.Progress) {
+		return worker.rejectStatusMessage(body, fmt.Errorf("progress %g for run %s is not between 0 and 100", *msg.Progress, msg.RunId))
+	}
+
+	if err := worker.updateRunStatus(msg, status); err != nil {
+		var staleErr *repositoryErrors.StaleMessageError
+		if errors.As(err, &staleErr) {
+			return worker.ignoreMessage(err)
 		}
-	default:
-		return fmt.Errorf("unsupported status %s for job %s", status, worker.Job.Name), false
+
+		var invalidIdErr *repositoryErrors.InvalidIdError
+		var notFoundErr *repositoryErrors.NotFoundError
+		var invalidTransitionErr *repositoryErrors.InvalidTransitionError
+		if errors.As(err, &invalidIdErr) || errors.As(err, &notFoundErr) || errors.As(err, &invalidTransitionErr) {
+			return worker.rejectStatusMessage(body, err)
+		}
+
+		return fmt.Errorf("failed to update run %s status to %s: %s", msg.RunId, status, err), true
 	}
 
+	worker.RunFinished(msg.RunId, status)
+
 	return nil, false
 }
 
+// rejectStatusMessage publishes a status message that cannot be applied, such
+// as one that would move a finished run, to the job's dead letter queue so that
+// it is neither lost nor redelivered.
+func (worker *JobWorker) rejectStatusMessage(body []byte, reason error) (error, bool) {
+	rejected := worker.rejectedMessages.Add(1)
+	log.Printf("Rejected status message for job %s, %d rejected so far: %s", worker.job().Name, rejected, reason)
+
+	deadLetter, err := json.Marshal(dtos.DeadLetterMessage{
+		Queue: worker.statusQueue,
+		Error: reason.Error(),
+		Body:  string(body),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize dead letter for job %s: %s", worker.job().Name, err), false
+	}
+
+	err = worker.MessageBus.Publish(
+		"scheduler.job."+worker.job().Name,
+		"deadletter",
+		deadLetter,
+	)
+	if errors.Is(err, messageBus.ErrNoSubscribers) {
+		// Message buses without a dead letter queue, such as webhooks, report
+		// the rejection to the sender instead
+		log.Printf("Dropped dead letter for job %s: %s", worker.job().Name, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to publish dead letter for job %s: %s", worker.job().Name, err), true
+	}
+
+	return reason, false
+}
+
+// RejectedMessages returns the number of status messages the worker has
+// dead-lettered since it was created.
+func (worker *JobWorker) RejectedMessages() int64 {
+	return worker.rejectedMessages.Load()
+}
+
+// ignoreMessage acknowledges a status or heartbeat message that has already
+// been applied, or that is older than the last message applied to its run,
+// without applying it again.
+func (worker *JobWorker) ignoreMessage(reason error) (error, bool) {
+	ignored := worker.ignoredMessages.Add(1)
+	log.Printf("Ignored message for job %s, %d ignored so far: %s", worker.job().Name, ignored, reason)
+	return nil, false
+}
+
+// IgnoredMessages returns the number of duplicate or stale status and
+// heartbeat messages the worker has ignored since it was created.
+func (worker *JobWorker) IgnoredMessages() int64 {
+	return worker.ignoredMessages.Load()
+}
+
 func (worker *JobWorker) heartbeatMessageReceived(body []byte) (error, bool) {
-	log.Printf("Job %s heartbeat message received: %s", worker.Job.Name, body)
+	log.Printf("Job %s heartbeat message received: %s", worker.job().Name, body)
 	var msg dtos.JobHeartbeatMessage
 	if err := json.Unmarshal(body, &msg); err != nil {
-		return fmt.Errorf("failed to deserialize heartbeat message for job %s: %s", worker.Job.Name, err), false
+		return fmt.Errorf("failed to deserialize heartbeat message for job %s: %s", worker.job().Name, err), false
 	}
 
-	if err := worker.updateRunHeartbeat(msg.RunId); err != nil {
+	if !validators.ValidateProgress(msg.Progress) {
+		return fmt.Errorf("progress %g for run %s is not between 0 and 100", *msg.Progress, msg.RunId), false
+	}
+
+	if err := worker.updateRunHeartbeat(msg); err != nil {
+		var staleErr *repositoryErrors.StaleMessageError
+		if errors.As(err, &staleErr) {
+			return worker.ignoreMessage(err)
+		}
+
+		var invalidIdErr *repositoryErrors.InvalidIdError
+		var notFoundErr *repositoryErrors.NotFoundError
+		if errors.As(err, &invalidIdErr) || errors.As(err, &notFoundErr) {
+			return fmt.Errorf("failed to update heartbeat for run %s: %s", msg.RunId, err), false
+		}
+
 		return fmt.Errorf("failed to update heartbeat for run %s: %s", msg.RunId, err), true
 	}
 
 	return nil, false
 }
 
-func (worker *JobWorker) setNextRunTime() error {
-	elapsed := time.Since(worker.Job.NextRunAt)
+func (worker *JobWorker) logMessageReceived(body []byte) (error, bool) {
+	var msg dtos.JobLogMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return fmt.Errorf("failed to deserialize log message for job %s: %s", worker.job().Name, err), false
+	}
+
+	if err := worker.addRunLog(msg); err != nil {
+		var invalidIdErr *repositoryErrors.InvalidIdError
+		if errors.As(err, &invalidIdErr) {
+			return fmt.Errorf("failed to add log for run %s: %s", msg.RunId, err), false
+		}
+
+		return fmt.Errorf("failed to add log for run %s: %s", msg.RunId, err), true
+	}
+
+	return nil, false
+}
+
+func (worker *JobWorker) maxRunLogLines() int64 {
+	if worker.MaxRunLogLines <= 0 {
+		return defaultMaxRunLogLines
+	}
+
+	return int64(worker.MaxRunLogLines)
+}
+
+// addRunLog stores a log line of a run. Once a run has reached the maximum
+// number of lines, its last line notes that the log was truncated and later
+// lines are dropped.
+func (worker *JobWorker) addRunLog(msg dtos.JobLogMessage) error {
+	worker.logCountsLock.Lock()
+	defer worker.logCountsLock.Unlock()
+
+	if worker.logCounts == nil {
+		worker.logCounts = map[string]int64{}
+	}
+
+	count, found := worker.logCounts[msg.RunId]
+	if !found {
+		filter := dtos.RunLogFilter{
+			RunId: &msg.RunId,
+		}
+		existing, err := worker.RunLogRepo.Count(filter)
+		if err != nil {
+			return fmt.Errorf("failed to count logs: %w", err)
+		}
+
+		count = existing
+	}
 
-	if worker.Job.Interval <= 0 || elapsed.Milliseconds() <= 0 {
+	maxLines := worker.maxRunLogLines()
+	if count >= maxLines {
+		worker.logCounts[msg.RunId] = count
 		return nil
 	}
 
-	intervals := (elapsed.Milliseconds() / int64(worker.Job.Interval)) + 1
-	tilNextRun := time.Duration(worker.Job.Interval * int(intervals) * int(time.Millisecond))
-	nextRunAt := worker.Job.NextRunAt.Add(tilNextRun)
+	runLog := dtos.RunLog{
+		JobName: worker.job().Name,
+		RunId:   msg.RunId,
+		Time:    msg.Time,
+		Level:   msg.Level,
+		Message: msg.Message,
+	}
+	if runLog.Time.IsZero() {
+		runLog.Time = time.Now()
+	}
+
+	if count == maxLines-1 {
+		runLog.Time = time.Now()
+		runLog.Level = "warn"
+		runLog.Message = fmt.Sprintf("log truncated at %d lines", maxLines)
+	}
+
+	if _, err := worker.RunLogRepo.Add(runLog); err != nil {
+		return fmt.Errorf("failed to store log: %w", err)
+	}
+
+	worker.logCounts[msg.RunId] = count + 1
+	return nil
+}
+
+// forgetRunLogCount stops tracking the number of log lines of a finished run.
+// Lines that arrive later are counted again from the database.
+func (worker *JobWorker) forgetRunLogCount(runId string) {
+	worker.logCountsLock.Lock()
+	defer worker.logCountsLock.Unlock()
+	delete(worker.logCounts, runId)
+}
+
+// nextOccurrence returns the first occurrence of the job after the given time
+// or false if the job does not recur.
+func (worker *JobWorker) nextOccurrence(after time.Time) (time.Time, bool, error) {
+	job := worker.job()
+	if job.Schedule != "" {
+		next, err := schedules.NextCronTime(job.Schedule, job.TimeZone, worker.DefaultTimeZone, after)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+
+		return next, !next.IsZero(), nil
+	}
+
+	if job.Interval <= 0 {
+		return time.Time{}, false, nil
+	}
+
+	elapsed := after.Sub(job.NextRunAt)
+	if elapsed < 0 {
+		return job.NextRunAt, true, nil
+	}
+
+	intervals := (elapsed.Milliseconds() / int64(job.Interval)) + 1
+	tilNextRun := time.Duration(job.Interval * int(intervals) * int(time.Millisecond))
+	return job.NextRunAt.Add(tilNextRun), true, nil
+}
+
+// setNextRunTime moves the job to its first occurrence after now. Jobs that do
+// not recur are disabled once they have run and false is returned.
+func (worker *JobWorker) setNextRunTime() (bool, error) {
+	nextRunAt, found, err := worker.nextOccurrence(time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	if !found {
+		enabled := false
+		update := dtos.JobUpdate{
+			Enabled: &enabled,
+		}
+
+		if err := worker.JobRepo.Edit(worker.job().Name, update); err != nil {
+			return false, fmt.Errorf("failed to disable job: %s", err)
+		}
+
+		worker.jobLock.Lock()
+		worker.Job.Enabled = false
+		worker.jobEditedAt = time.Now()
+		worker.jobLock.Unlock()
+		return false, nil
+	}
+
 	update := dtos.JobUpdate{
 		NextRunAt: &nextRunAt,
 	}
 
-	if err := worker.JobRepo.Edit(worker.Job.Name, update); err != nil {
-		return fmt.Errorf("failed to set next run time: %s", err)
+	if err := worker.JobRepo.Edit(worker.job().Name, update); err != nil {
+		return false, fmt.Errorf("failed to set next run time: %s", err)
 	}
 
+	worker.jobLock.Lock()
 	worker.Job.NextRunAt = nextRunAt
+	worker.jobEditedAt = time.Now()
+	worker.jobLock.Unlock()
 
-	return nil
+	return true, nil
 }
 
-func (worker *JobWorker) startRun() error {
+func (worker *JobWorker) misfireThreshold() time.Duration {
+	if worker.job().MisfireThreshold <= 0 {
+		return defaultMisfireThreshold
+	}
+
+	return time.Duration(worker.job().MisfireThreshold) * time.Millisecond
+}
+
+// runDue starts the run that is due at the job's next run time. If the timer
+// fired later than the misfire threshold, the job's misfire policy decides
+// which of the missed occurrences are run.
+func (worker *JobWorker) runDue() error {
+	lateness := time.Since(worker.job().NextRunAt)
+	if lateness <= worker.misfireThreshold() {
+		return worker.startRun(worker.job().NextRunAt)
+	}
+
+	switch misfirePolicies.MisfirePolicy(worker.job().MisfirePolicy) {
+	case misfirePolicies.Skip:
+		return worker.skipRun(
+			worker.job().NextRunAt,
+			fmt.Sprintf("misfired by %s", lateness.Round(time.Millisecond)),
+		)
+	case misfirePolicies.FireAll:
+		now := time.Now()
+		scheduledAt := worker.job().NextRunAt
+		errs := []error{}
+		for i := 0; i < maxMisfiredRuns && !scheduledAt.After(now); i++ {
+			if err := worker.startRun(scheduledAt); err != nil {
+				errs = append(errs, err)
+			}
+
+			next, found, err := worker.nextOccurrence(scheduledAt)
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+			if !found {
+				break
+			}
+
+			scheduledAt = next
+		}
+
+		return errors.Join(errs...)
+	default:
+		return worker.startRun(worker.job().NextRunAt)
+	}
+}
+
+// admitRun returns the status a new run of the job starts in according to the
+// job's maxQueueCount and concurrencyPolicy, with the reason if the run is
+// queued or skipped instead of pending.
+func (worker *JobWorker) admitRun(job dtos.Job) (runStatuses.RunStatus, string, error) {
+	if job.MaxQueueCount > 0 {
+		filter := dtos.RunFilter{
+			JobName:  &job.Name,
+			Statuses: []runStatuses.RunStatus{runStatuses.Queued, runStatuses.Pending},
+		}
+		count, err := worker.RunRepo.Count(filter)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to count queued runs for job %s: %s", job.Name, err)
+		}
+
+		if count >= int64(job.MaxQueueCount) {
+			return runStatuses.Skipped, fmt.Sprintf("max queue count of %d reached", job.MaxQueueCount), nil
+		}
+	}
+
+	if !job.AllowConcurrentRuns {
+		filter := dtos.RunFilter{
+			JobName: &job.Name,
+			Statuses: []runStatuses.RunStatus{
+				runStatuses.Queued,
+				runStatuses.Pending,
+				runStatuses.Running,
+				runStatuses.Cancelling,
+			},
+		}
+		activeRuns, err := worker.RunRepo.Browse(filter)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get active runs for job %s: %s", job.Name, err)
+		}
+
+		if len(activeRuns) > 0 {
+			activeRun := activeRuns[len(activeRuns)-1]
+			if concurrencyPolicies.ConcurrencyPolicy(job.ConcurrencyPolicy) == concurrencyPolicies.Queue {
+				return runStatuses.Queued, fmt.Sprintf("queued behind run %s", activeRun.Id), nil
+			}
+
+			return runStatuses.Skipped, fmt.Sprintf("run %s is %s and concurrent runs are not allowed", activeRun.Id, activeRun.Status), nil
+		}
+	}
+
+	return runStatuses.Pending, "", nil
+}
+
+func (worker *JobWorker) startRun(scheduledAt time.Time) error {
+	job := worker.job()
+	worker.runsLock.Lock()
+	defer worker.runsLock.Unlock()
+
+	status, reason, err := worker.admitRun(job)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case runStatuses.Skipped:
+		return worker.skipRun(scheduledAt, reason)
+	case runStatuses.Queued:
+		return worker.queueRun(scheduledAt, reason)
+	}
+
 	run := dtos.Run{
-		JobName:     worker.Job.Name,
+		JobName:     job.Name,
 		Status:      runStatuses.Pending,
-		CreatedTime: worker.Job.NextRunAt,
-		Heartbeat:   worker.Job.NextRunAt,
+		CreatedTime: scheduledAt,
+		Period:      scheduledAt,
+		Heartbeat:   scheduledAt,
+		Attempt:     1,
+		Parameters:  job.Parameters,
+		RoutingKey:  worker.routingKey(),
+	}
+	if message := outbox.NewJobMessage(job, worker.runAction(run), scheduledAt); message != nil {
+		run.Outbox = []dtos.OutboxMessage{*message}
 	}
 	runId, err := worker.RunRepo.Add(run)
 	if err != nil {
-		return fmt.Errorf("failed to add run for job %s: %s", worker.Job.Name, err)
+		return fmt.Errorf("failed to add run for job %s: %s", job.Name, err)
+	}
+
+	worker.dispatchRun(runId)
+
+	log.Printf("Started run %s for job %s", runId, job.Name)
+	return nil
+}
+
+// runAction returns the run action stored in the outbox of a run. The job name
+// and run ID are set from the run when it is dispatched.
+func (worker *JobWorker) runAction(run dtos.Run) dtos.JobActionMessage {
+	return dtos.JobActionMessage{
+		Action:     string(jobActions.Run),
+		Attempt:    max(run.Attempt, 1),
+		Parameters: run.Parameters,
+	}
+}
+
+// routingKey returns the key run actions are published with so that they are
+// only performed by workers in the job's pool that have all of its tags.
+func (worker *JobWorker) routingKey() string {
+	return workerPools.RoutingKey(worker.job().WorkerPool, worker.job().WorkerTags)
+}
+
+// registerPoolQueue binds the action queue of the job's worker pool and tags
+// so that actions routed to it are kept until a worker in the pool subscribes.
+// Jobs in the default pool without tags use the action queue.
+func (worker *JobWorker) registerPoolQueue() error {
+	routingKey := worker.routingKey()
+	if routingKey == workerPools.RoutingKey("", nil) {
+		return nil
+	}
+
+	err := worker.MessageBus.Register(
+		"scheduler.job."+worker.job().Name,
+		map[string][]string{
+			workerPools.ActionQueue(worker.job().Name, routingKey): {routingKey},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register worker pool queue for job %s to message bus: %s", worker.job().Name, err)
+	}
+
+	return nil
+}
+
+// dispatchRun publishes the actions stored with a run straight away. Actions
+// that fail to publish are retried by the next dispatch of the job's outbox.
+func (worker *JobWorker) dispatchRun(runId string) {
+	if err := worker.Dispatcher.DispatchRun(runId); err != nil {
+		log.Printf("Failed to dispatch actions of run %s for job %s, will retry: %s", runId, worker.job().Name, err)
+	}
+}
+
+// startRequestedRuns publishes the runs requested through the API. These are
+// started straight away and do not change the job's next run time.
+func (worker *JobWorker) startRequestedRuns() error {
+	job := worker.job()
+	worker.runsLock.Lock()
+	defer worker.runsLock.Unlock()
+
+	// Requested runs wait until the job is enabled again
+	if !job.Enabled {
+		return nil
+	}
+
+	requestedStatus := runStatuses.Requested
+	filter := dtos.RunFilter{
+		JobName: &job.Name,
+		Status:  &requestedStatus,
+	}
+	runs, err := worker.RunRepo.Browse(filter)
+	if err != nil {
+		return fmt.Errorf("failed to get requested runs for job %s: %s", job.Name, err)
 	}
 
-	body, err := json.Marshal(dtos.JobActionMessage{
-		JobName: worker.Job.Name,
-		RunId:   runId,
-		Action:  "run",
+	// Requested runs, such as manual runs and runs released by upstream jobs,
+	// are queued or skipped the same as scheduled runs
+	errs := []error{}
+	routingKey := worker.routingKey()
+	for _, run := range runs {
+		status, reason, err := worker.admitRun(job)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to start requested run %s: %s", run.Id, err))
+			continue
+		}
+
+		runUpdate := dtos.RunUpdate{
+			Status: &status,
+		}
+		switch status {
+		case runStatuses.Pending:
+			runUpdate.Outbox = outbox.NewJobMessage(job, worker.runAction(run), time.Now())
+			runUpdate.RoutingKey = &routingKey
+		case runStatuses.Queued:
+			runUpdate.Reason = &reason
+		case runStatuses.Skipped:
+			endTime := time.Now()
+			runUpdate.Reason = &reason
+			runUpdate.EndTime = &endTime
+		}
+
+		if err := worker.RunRepo.Edit(run.Id, runUpdate); err != nil {
+			errs = append(errs, fmt.Errorf("failed to start requested run %s: %s", run.Id, err))
+			continue
+		}
+
+		switch status {
+		case runStatuses.Pending:
+			worker.dispatchRun(run.Id)
+			log.Printf("Started requested run %s for job %s", run.Id, job.Name)
+		case runStatuses.Queued:
+			log.Printf("Queued requested run %s for job %s because %s", run.Id, job.Name, reason)
+		case runStatuses.Skipped:
+			log.Printf("Skipped requested run %s for job %s because %s", run.Id, job.Name, reason)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// handleReportedRuns applies the job's policies to leased runs whose status
+// was reported through the API, as those do not send a status message.
+func (worker *JobWorker) handleReportedRuns() error {
+	job := worker.job()
+	reported := true
+	filter := dtos.RunFilter{
+		JobName:  &job.Name,
+		Reported: &reported,
+	}
+	runs, err := worker.RunRepo.Browse(filter)
+	if err != nil {
+		return fmt.Errorf("failed to get reported runs for job %s: %s", job.Name, err)
+	}
+
+	errs := []error{}
+	handled := false
+	for _, run := range runs {
+		runUpdate := dtos.RunUpdate{
+			Reported: &handled,
+		}
+		if err := worker.RunRepo.Edit(run.Id, runUpdate); err != nil {
+			errs = append(errs, fmt.Errorf("failed to handle reported run %s: %s", run.Id, err))
+			continue
+		}
+
+		worker.RunFinished(run.Id, run.Status)
+
+		log.Printf("Handled reported status %s of run %s for job %s", run.Status, run.Id, job.Name)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (worker *JobWorker) queueRun(scheduledAt time.Time, reason string) error {
+	run := dtos.Run{
+		JobName:     worker.job().Name,
+		Status:      runStatuses.Queued,
+		CreatedTime: scheduledAt,
+		Period:      scheduledAt,
+		Heartbeat:   scheduledAt,
+		Reason:      reason,
+		Attempt:     1,
+		Parameters:  worker.job().Parameters,
+	}
+	runId, err := worker.RunRepo.Add(run)
+	if err != nil {
+		return fmt.Errorf("failed to add queued run for job %s: %s", worker.job().Name, err)
+	}
+
+	log.Printf("Queued run %s for job %s because %s", runId, worker.job().Name, reason)
+	return nil
+}
+
+func (worker *JobWorker) releaseQueuedRun() error {
+	job := worker.job()
+	worker.runsLock.Lock()
+	defer worker.runsLock.Unlock()
+
+	// Queued runs wait until the job is enabled again
+	if !job.Enabled {
+		return nil
+	}
+
+	activeFilter := dtos.RunFilter{
+		JobName: &job.Name,
+		Statuses: []runStatuses.RunStatus{
+			runStatuses.Pending,
+			runStatuses.Running,
+			runStatuses.Cancelling,
+		},
+	}
+	activeCount, err := worker.RunRepo.Count(activeFilter)
+	if err != nil {
+		return fmt.Errorf("failed to count active runs for job %s: %s", job.Name, err)
+	}
+
+	if activeCount > 0 {
+		return nil
+	}
+
+	queuedStatus := runStatuses.Queued
+	queuedFilter := dtos.RunFilter{
+		JobName: &job.Name,
+		Status:  &queuedStatus,
+	}
+	queuedRuns, err := worker.RunRepo.Browse(queuedFilter)
+	if err != nil {
+		return fmt.Errorf("failed to get queued runs for job %s: %s", job.Name, err)
+	}
+
+	if len(queuedRuns) == 0 {
+		return nil
+	}
+
+	run := slices.MinFunc(queuedRuns, func(a dtos.Run, b dtos.Run) int {
+		return a.CreatedTime.Compare(b.CreatedTime)
 	})
+	pendingStatus := runStatuses.Pending
+	routingKey := worker.routingKey()
+	runUpdate := dtos.RunUpdate{
+		Status:     &pendingStatus,
+		Outbox:     outbox.NewJobMessage(job, worker.runAction(run), time.Now()),
+		RoutingKey: &routingKey,
+	}
+	if err := worker.RunRepo.Edit(run.Id, runUpdate); err != nil {
+		return fmt.Errorf("failed to release queued run %s for job %s: %s", run.Id, job.Name, err)
+	}
+
+	worker.dispatchRun(run.Id)
+
+	log.Printf("Released queued run %s for job %s", run.Id, job.Name)
+	return nil
+}
+
+func (worker *JobWorker) skipRun(scheduledAt time.Time, reason string) error {
+	now := time.Now()
+	run := dtos.Run{
+		JobName:     worker.job().Name,
+		Status:      runStatuses.Skipped,
+		CreatedTime: scheduledAt,
+		Period:      scheduledAt,
+		EndTime:     now,
+		Heartbeat:   scheduledAt,
+		Reason:      reason,
+		Attempt:     1,
+		Parameters:  worker.job().Parameters,
+	}
+	runId, err := worker.RunRepo.Add(run)
 	if err != nil {
-		return fmt.Errorf("failed to serialize run action %s: %s", runId, err)
+		return fmt.Errorf("failed to add skipped run for job %s: %s", worker.job().Name, err)
 	}
 
-	err = worker.MessageBus.Publish(
-		"scheduler.job."+worker.Job.Name,
-		"action",
-		body,
-	)
+	log.Printf("Skipped run %s for job %s because %s", runId, worker.job().Name, reason)
+	return nil
+}
+
+// releaseDependentRuns requests a run of each job that depends on this job once
+// all of its upstream jobs have completed a run for the same period as the
+// given run. The requested runs are published by the schedulers managing them.
+func (worker *JobWorker) releaseDependentRuns(runId string) error {
+	jobs, err := worker.JobRepo.Browse()
+	if err != nil {
+		return fmt.Errorf("failed to get jobs: %s", err)
+	}
+
+	dependents := workflows.Dependents(worker.job().Name, jobs)
+	if len(dependents) == 0 {
+		return nil
+	}
+
+	run, err := worker.RunRepo.Read(runId)
+	if err != nil {
+		return fmt.Errorf("failed to read run %s: %s", runId, err)
+	}
+
+	errs := []error{}
+	for _, dependent := range dependents {
+		if !dependent.Enabled {
+			continue
+		}
+
+		released, err := worker.releaseDependentRun(dependent, run.Period)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to release run of job %s: %s", dependent.Name, err))
+		} else if released {
+			log.Printf("Requested run of job %s for period %s after job %s completed", dependent.Name, run.Period, worker.job().Name)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (worker *JobWorker) releaseDependentRun(dependent dtos.Job, period time.Time) (bool, error) {
+	existingFilter := dtos.RunFilter{
+		JobName: &dependent.Name,
+		Period:  &period,
+	}
+	existingCount, err := worker.RunRepo.Count(existingFilter)
 	if err != nil {
-		return fmt.Errorf("failed to publish run action %s: %s", runId, err)
+		return false, err
+	}
+
+	if existingCount > 0 {
+		return false, nil
+	}
+
+	completedStatus := runStatuses.Completed
+	for _, upstream := range dependent.DependsOn {
+		completedFilter := dtos.RunFilter{
+			JobName: &upstream,
+			Status:  &completedStatus,
+			Period:  &period,
+		}
+		completedCount, err := worker.RunRepo.Count(completedFilter)
+		if err != nil {
+			return false, err
+		}
+
+		if completedCount == 0 {
+			return false, nil
+		}
 	}
 
+	now := time.Now()
+	run := dtos.Run{
+		JobName:     dependent.Name,
+		Status:      runStatuses.Requested,
+		CreatedTime: now,
+		Period:      period,
+		Heartbeat:   now,
+		Reason:      fmt.Sprintf("upstream jobs %s completed", strings.Join(dependent.DependsOn, ", ")),
+		Attempt:     1,
+		Parameters:  dependent.Parameters,
+		Released:    true,
+	}
+	if _, err := worker.RunRepo.Add(run); err != nil {
+		// Another upstream job released the run at the same time
+		var duplicateErr *repositoryErrors.DuplicateError
+		if errors.As(err, &duplicateErr) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RunFinished applies the job's policies to a run that has moved to a status,
+// retrying it or releasing dependent and queued runs if the status is
+// terminal. It is called for statuses sent by clients and for runs finished by
+// the run custodian, so that both are handled the same.
+func (worker *JobWorker) RunFinished(runId string, status runStatuses.RunStatus) {
+	switch status {
+	case runStatuses.Completed:
+		if err := worker.releaseDependentRuns(runId); err != nil {
+			log.Printf("Failed to release dependent runs of run %s for job %s: %s", runId, worker.job().Name, err)
+		}
+	case runStatuses.Cancelled, runStatuses.Failed:
+		if err := worker.retryRun(runId, status); err != nil {
+			log.Printf("Failed to retry run %s for job %s: %s", runId, worker.job().Name, err)
+		}
+	}
+
+	switch status {
+	case runStatuses.Cancelled, runStatuses.Completed, runStatuses.Failed:
+		worker.forgetRunLogCount(runId)
+
+		if err := worker.releaseQueuedRun(); err != nil {
+			log.Printf("Failed to release queued run for job %s: %s", worker.job().Name, err)
+		}
+	}
+}
+
+// retryRun adds another attempt of a failed run, or of a run cancelled because
+// it timed out, if the job's retry policy allows it. The attempt is published
+// once its backoff delay has elapsed.
+func (worker *JobWorker) retryRun(runId string, status runStatuses.RunStatus) error {
+	job := worker.job()
+	policy := job.RetryPolicy
+	if !job.Enabled || policy.MaxAttempts <= 1 {
+		return nil
+	}
+
+	run, err := worker.RunRepo.Read(runId)
+	if err != nil {
+		return fmt.Errorf("failed to read run %s: %s", runId, err)
+	}
+
+	if status == runStatuses.Cancelled && !(policy.RetryOnTimeout && run.TimedOut) {
+		return nil
+	}
+
+	attempt := max(run.Attempt, 1)
+	if attempt >= policy.MaxAttempts {
+		return nil
+	}
+
+	originalRunId := run.OriginalRunId
+	if originalRunId == "" {
+		originalRunId = run.Id
+	}
+
+	delay := worker.retryDelay(attempt)
+	retryAt := time.Now().Add(delay)
+	retry := dtos.Run{
+		JobName:       job.Name,
+		Status:        runStatuses.Pending,
+		CreatedTime:   retryAt,
+		Period:        run.Period,
+		Heartbeat:     retryAt,
+		Reason:        fmt.Sprintf("retry of run %s", run.Id),
+		Attempt:       attempt + 1,
+		OriginalRunId: originalRunId,
+		Parameters:    run.Parameters,
+		RoutingKey:    worker.routingKey(),
+	}
+	if message := outbox.NewJobMessage(job, worker.runAction(retry), retryAt); message != nil {
+		retry.Outbox = []dtos.OutboxMessage{*message}
+	}
+	retryId, err := worker.RunRepo.Add(retry)
+	if err != nil {
+		return fmt.Errorf("failed to add retry of run %s: %s", run.Id, err)
+	}
+
+	worker.retryTimersLock.Lock()
+	defer worker.retryTimersLock.Unlock()
+
+	if worker.retryTimers == nil {
+		worker.retryTimers = map[string]*time.Timer{}
+	}
+
+	worker.retryTimers[retryId] = time.AfterFunc(delay, func() {
+		worker.retryTimersLock.Lock()
+		delete(worker.retryTimers, retryId)
+		worker.retryTimersLock.Unlock()
+
+		worker.dispatchRun(retryId)
+
+		log.Printf("Started attempt %d of run %s for job %s", retry.Attempt, originalRunId, job.Name)
+	})
+
+	log.Printf("Retrying run %s for job %s as %s in %s", run.Id, job.Name, retryId, delay)
 	return nil
 }
 
-func (worker *JobWorker) updateRunStatus(runId string, status runStatuses.RunStatus) error {
+func (worker *JobWorker) retryDelay(attempt int) time.Duration {
+	policy := worker.job().RetryPolicy
+	delay := time.Duration(policy.Delay) * time.Millisecond
+	maxDelay := time.Duration(policy.MaxDelay) * time.Millisecond
+
+	if backoffStrategies.BackoffStrategy(policy.Backoff) == backoffStrategies.Exponential {
+		for i := 1; i < attempt && delay < math.MaxInt64/2; i++ {
+			delay *= 2
+			if maxDelay > 0 && delay >= maxDelay {
+				break
+			}
+		}
+	}
+
+	if maxDelay > 0 && delay > maxDelay {
+		return maxDelay
+	}
+
+	return delay
+}
+
+// messageUpdate returns a run update that only applies once for messages with
+// an ID. Messages without one are always applied.
+func messageUpdate(messageId string) dtos.RunUpdate {
+	runUpdate := dtos.RunUpdate{}
+	if messageId != "" {
+		runUpdate.MessageId = &messageId
+	}
+
+	return runUpdate
+}
+
+// setProgress sets the progress reported by a client. The progress message is
+// kept until the client reports a new one.
+func setProgress(runUpdate *dtos.RunUpdate, progress *float64, progressMessage string) {
+	runUpdate.Progress = progress
+	if progressMessage != "" {
+		runUpdate.ProgressMessage = &progressMessage
+	}
+}
+
+func (worker *JobWorker) updateRunStatus(msg dtos.JobStatusMessage, status runStatuses.RunStatus) error {
+	runId := msg.RunId
 	now := time.Now()
-	runUpdate := dtos.RunUpdate{
-		Status: &status,
+	runUpdate := messageUpdate(msg.MessageId)
+	if msg.Sequence > 0 {
+		runUpdate.Sequence = &msg.Sequence
+	}
+	runUpdate.Status = &status
+	setProgress(&runUpdate, msg.Progress, msg.ProgressMessage)
+	if msg.Result != nil {
+		runUpdate.Result = &msg.Result
+	}
+	if msg.Error != "" {
+		runUpdate.Error = &msg.Error
 	}
+	if msg.ErrorCode != "" {
+		runUpdate.ErrorCode = &msg.ErrorCode
+	}
+	runUpdate.ExitCode = msg.ExitCode
+
 	switch status {
 	case runStatuses.Cancelled, runStatuses.Completed, runStatuses.Failed:
 		runUpdate.EndTime = &now
-	case runStatuses.Cancelling:
-	case runStatuses.Pending:
 	case runStatuses.Running:
 		runUpdate.StartTime = &now
 		runUpdate.Heartbeat = &now
+		if msg.WorkerId != "" {
+			runUpdate.WorkerId = &msg.WorkerId
+		}
 	default:
 		return fmt.Errorf("unsupported status %s", status)
 	}
 
 	if err := worker.RunRepo.Edit(runId, runUpdate); err != nil {
-		return fmt.Errorf("failed to edit run %s for job %s: %s", runId, worker.Job.Name, err)
+		return fmt.Errorf("failed to edit run %s for job %s: %w", runId, worker.job().Name, err)
 	}
 
 	return nil
 }
 
-func (worker *JobWorker) updateRunHeartbeat(runId string) error {
+func (worker *JobWorker) updateRunHeartbeat(msg dtos.JobHeartbeatMessage) error {
 	now := time.Now()
-	runUpdate := dtos.RunUpdate{
-		Heartbeat: &now,
+	runUpdate := messageUpdate(msg.MessageId)
+	if msg.Sequence > 0 {
+		runUpdate.HeartbeatSequence = &msg.Sequence
 	}
+	runUpdate.Heartbeat = &now
+	setProgress(&runUpdate, msg.Progress, msg.ProgressMessage)
 
-	if err := worker.RunRepo.Edit(runId, runUpdate); err != nil {
-		return fmt.Errorf("failed to edit run %s for job %s: %s", runId, worker.Job.Name, err)
+	if err := worker.RunRepo.Edit(msg.RunId, runUpdate); err != nil {
+		return fmt.Errorf("failed to edit run %s for job %s: %w", msg.RunId, worker.job().Name, err)
 	}
 
 	return nil
 }
 
+// applyJob replaces the job definition and reschedules the next run if the
+// job was enabled, disabled, moved or given upstream jobs. Runs started after
+// the job is moved to another worker pool are routed to it. Definitions read
+// before the worker last edited the job are ignored, as they would move the
+// next run time back to a run that has already started.
+func (worker *JobWorker) applyJob(update jobUpdate, nextRunTimer *time.Timer) {
+	worker.jobLock.RLock()
+	current := worker.Job
+	editedAt := worker.jobEditedAt
+	worker.jobLock.RUnlock()
+
+	job := update.job
+	if update.readAt.Before(editedAt) {
+		log.Printf("Ignored change of job %s read before its next run time was set", job.Name)
+		return
+	}
+
+	rescheduled := job.Enabled != current.Enabled ||
+		!job.NextRunAt.Equal(current.NextRunAt) ||
+		!slices.Equal(job.DependsOn, current.DependsOn)
+	rerouted := job.WorkerPool != current.WorkerPool ||
+		!slices.Equal(job.WorkerTags, current.WorkerTags)
+	worker.jobLock.Lock()
+	worker.Job = job
+	worker.jobLock.Unlock()
+
+	if rerouted {
+		if err := worker.registerPoolQueue(); err != nil {
+			log.Printf("Failed to route runs of job %s to worker pool: %s", job.Name, err)
+		}
+	}
+
+	if rescheduled {
+		worker.scheduleNextRun(nextRunTimer)
+		if job.Enabled {
+			log.Printf("Job %s was changed, next run is at %s", job.Name, job.NextRunAt.String())
+		} else {
+			log.Printf("Job %s was disabled", job.Name)
+		}
+	}
+}
+
+// scheduleNextRun resets the timer to the job's next run time. Disabled jobs
+// and jobs with upstream jobs, which are only run once those have completed,
+// are not run on a schedule.
+func (worker *JobWorker) scheduleNextRun(nextRunTimer *time.Timer) {
+	if !worker.job().Enabled || len(worker.job().DependsOn) > 0 {
+		nextRunTimer.Stop()
+		return
+	}
+
+	nextRunTimer.Reset(time.Until(worker.job().NextRunAt))
+}
+
 func (worker *JobWorker) process(wg *sync.WaitGroup) {
 	wg.Add(1)
 	defer wg.Done()
 
-	nextRunTimer := time.NewTimer(time.Until(worker.Job.NextRunAt))
+	nextRunTimer := time.NewTimer(time.Until(worker.job().NextRunAt))
 	defer nextRunTimer.Stop()
+	worker.scheduleNextRun(nextRunTimer)
+
+	requestPollDuration := worker.RequestPollDuration
+	if requestPollDuration <= 0 {
+		requestPollDuration = defaultRequestPollDuration
+	}
+
+	requestTicker := time.NewTicker(requestPollDuration)
+	defer requestTicker.Stop()
 
 	for {
 		select {
 		case <-worker.quit:
-			log.Printf("Stopped job %s", worker.Job.Name)
+			log.Printf("Stopped job %s", worker.job().Name)
 			worker.stopped()
 			return
+		case update := <-worker.jobUpdates:
+			worker.applyJob(update, nextRunTimer)
+		case <-requestTicker.C:
+			if err := worker.startRequestedRuns(); err != nil {
+				log.Printf("Failed to start requested runs for job %s: %s", worker.job().Name, err)
+			}
+
+			if err := worker.handleReportedRuns(); err != nil {
+				log.Printf("Failed to handle reported runs for job %s: %s", worker.job().Name, err)
+			}
+
+			if err := worker.Dispatcher.DispatchJob(worker.job().Name); err != nil {
+				log.Printf("Failed to dispatch unsent actions for job %s: %s", worker.job().Name, err)
+			}
 		case <-nextRunTimer.C:
-			log.Printf("Starting run for job %s...", worker.Job.Name)
+			if !worker.job().Enabled || len(worker.job().DependsOn) > 0 {
+				continue
+			}
 
-			if err := worker.startRun(); err != nil {
-				log.Printf("Failed to start run for job %s: %s", worker.Job.Name, err)
-			} else {
-				log.Printf("Started run for job %s", worker.Job.Name)
+			if err := worker.releaseQueuedRun(); err != nil {
+				log.Printf("Failed to release queued run for job %s: %s", worker.job().Name, err)
+			}
+
+			log.Printf("Starting run for job %s...", worker.job().Name)
+
+			if err := worker.runDue(); err != nil {
+				log.Printf("Failed to start run for job %s: %s", worker.job().Name, err)
 			}
 
-			if err := worker.setNextRunTime(); err != nil {
-				log.Printf("Failed to update next run time for job %s: %s", worker.Job.Name, err)
+			if found, err := worker.setNextRunTime(); err != nil {
+				log.Printf("Failed to update next run time for job %s: %s", worker.job().Name, err)
 				worker.Stop()
+			} else if !found {
+				log.Printf("Job %s has no more runs and has been disabled", worker.job().Name)
 			} else {
-				log.Printf("Next run for job %s is at %s", worker.Job.Name, worker.Job.NextRunAt.String())
-				nextRunTimer.Reset(time.Until(worker.Job.NextRunAt))
+				log.Printf("Next run for job %s is at %s", worker.job().Name, worker.job().NextRunAt.String())
+				nextRunTimer.Reset(time.Until(worker.job().NextRunAt))
 			}
 		}
 	}
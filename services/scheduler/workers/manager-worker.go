@@ -10,6 +10,7 @@ import (
 	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
 	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
 	messageBus "github.com/jacobmcgowan/simple-scheduler/shared/message-bus"
+	"github.com/jacobmcgowan/simple-scheduler/shared/outbox"
 )
 
 type ManagerWorker struct {
@@ -20,9 +21,15 @@ type ManagerWorker struct {
 	ManagerRepo          repositories.ManagerRepository
 	JobRepo              repositories.JobRepository
 	RunRepo              repositories.RunRepository
+	RunLogRepo           repositories.RunLogRepository
 	CacheRefreshDuration time.Duration
 	CleanupDuration      time.Duration
 	HeartbeatDuration    time.Duration
+	RequestPollDuration  time.Duration
+	DefaultTimeZone      *time.Location
+	MaxRunLogLines       int
+	RunLogRetention      time.Duration
+	CancelGracePeriod    time.Duration
 	nextCacheRefreshAt   time.Time
 	jobsLock             sync.Mutex `default:"sync.Mutex{}"`
 	jobs                 map[string]*JobWorker
@@ -94,7 +101,8 @@ func (worker *ManagerWorker) refreshCache(wg *sync.WaitGroup) error {
 	worker.jobsLock.Lock()
 	defer worker.jobsLock.Unlock()
 
-	worker.nextCacheRefreshAt = time.Now().Add(worker.CacheRefreshDuration)
+	readAt := time.Now()
+	worker.nextCacheRefreshAt = readAt.Add(worker.CacheRefreshDuration)
 	refreshedJobs := make(map[string]bool)
 	filter := dtos.JobLockFilter{
 		ManagerId: worker.Id,
@@ -109,25 +117,38 @@ func (worker *ManagerWorker) refreshCache(wg *sync.WaitGroup) error {
 		log.Printf("Locked job %s for manager %s@%s", job.Name, worker.Id, worker.Hostname)
 		jobWorker, found := worker.jobs[job.Name]
 		if found {
-			jobWorker.Job = job
+			jobWorker.Update(job, readAt)
 		} else {
 			worker.jobs[job.Name] = &JobWorker{
 				Job:        job,
 				MessageBus: worker.MessageBus,
 				JobRepo:    worker.JobRepo,
 				RunRepo:    worker.RunRepo,
+				RunLogRepo: worker.RunLogRepo,
+				Dispatcher: &outbox.Dispatcher{
+					MessageBus: worker.MessageBus,
+					RunRepo:    worker.RunRepo,
+				},
+				DefaultTimeZone:     worker.DefaultTimeZone,
+				RequestPollDuration: worker.RequestPollDuration,
+				MaxRunLogLines:      worker.MaxRunLogLines,
 			}
 		}
 
 		runCustodian, found := worker.custodians[job.Name]
 		if found {
-			runCustodian.Job = job
+			runCustodian.Update(job)
 		} else {
 			worker.custodians[job.Name] = &RunCustodian{
-				Job:        job,
-				MessageBus: worker.MessageBus,
-				RunRepo:    worker.RunRepo,
-				Duration:   worker.CleanupDuration,
+				Job:               job,
+				MessageBus:        worker.MessageBus,
+				RunRepo:           worker.RunRepo,
+				RunLogRepo:        worker.RunLogRepo,
+				Dispatcher:        worker.jobs[job.Name].Dispatcher,
+				RunFinished:       worker.jobs[job.Name].RunFinished,
+				Duration:          worker.CleanupDuration,
+				RunLogRetention:   worker.RunLogRetention,
+				CancelGracePeriod: worker.CancelGracePeriod,
 			}
 		}
 
@@ -143,7 +164,7 @@ func (worker *ManagerWorker) refreshCache(wg *sync.WaitGroup) error {
 				jobErrs = append(jobErrs, fmt.Errorf("failed to start job %s: %s", name, err))
 			}
 		} else {
-			unlockJobNames = append(unlockJobNames, job.Job.Name)
+			unlockJobNames = append(unlockJobNames, name)
 			job.Stop()
 			delete(worker.jobs, name)
 		}
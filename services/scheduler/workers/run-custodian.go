@@ -1,10 +1,10 @@
 package workers
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"sync"
 	"time"
 
@@ -12,14 +12,32 @@ import (
 	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
 	"github.com/jacobmcgowan/simple-scheduler/shared/jobActions"
 	messageBus "github.com/jacobmcgowan/simple-scheduler/shared/message-bus"
+	"github.com/jacobmcgowan/simple-scheduler/shared/outbox"
 	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/timeoutActions"
 )
 
+const defaultMaxRestarts = 3
+
+const defaultRunLogRetention = time.Hour * 24 * 7
+
+const defaultCancelGracePeriod = time.Minute * 5
+
 type RunCustodian struct {
-	Job           dtos.Job
-	MessageBus    messageBus.MessageBus
-	RunRepo       repositories.RunRepository
-	Duration      time.Duration
+	Job             dtos.Job
+	jobLock         sync.RWMutex `default:"sync.RWMutex{}"`
+	MessageBus      messageBus.MessageBus
+	RunRepo         repositories.RunRepository
+	RunLogRepo      repositories.RunLogRepository
+	Dispatcher      *outbox.Dispatcher
+	Duration        time.Duration
+	RunLogRetention time.Duration
+	// CancelGracePeriod is used for jobs that do not set a cancel grace period.
+	CancelGracePeriod time.Duration
+	// RunFinished is called for runs the custodian moves to a terminal status,
+	// so that they are retried and release queued runs the same as runs
+	// finished by their clients.
+	RunFinished   func(runId string, status runStatuses.RunStatus)
 	quit          chan struct{}
 	isRunningLock sync.Mutex `default:"sync.Mutex{}"`
 	isRunning     bool
@@ -36,8 +54,8 @@ func (worker *RunCustodian) Start(wg *sync.WaitGroup) error {
 		return nil
 	}
 
-	log.Printf("Starting run custodian for job %s...", worker.Job.Name)
-	fullName := "scheduler.job." + worker.Job.Name
+	log.Printf("Starting run custodian for job %s...", worker.job().Name)
+	fullName := "scheduler.job." + worker.job().Name
 	worker.statusQueue = fullName + ".status"
 	worker.actionQueue = fullName + ".action"
 	err := worker.MessageBus.Register(
@@ -48,17 +66,32 @@ func (worker *RunCustodian) Start(wg *sync.WaitGroup) error {
 		},
 	)
 	if err != nil {
-		return fmt.Errorf("failed to register job %s to message bus: %s", worker.Job.Name, err)
+		return fmt.Errorf("failed to register job %s to message bus: %s", worker.job().Name, err)
 	}
 
 	worker.quit = make(chan struct{})
 	go worker.process(wg)
 	worker.isRunning = true
 
-	log.Printf("Started run custodian for job %s", worker.Job.Name)
+	log.Printf("Started run custodian for job %s", worker.job().Name)
 	return nil
 }
 
+// job returns the job definition, which may be replaced through Update while
+// the custodian is running.
+func (worker *RunCustodian) job() dtos.Job {
+	worker.jobLock.RLock()
+	defer worker.jobLock.RUnlock()
+	return worker.Job
+}
+
+// Update replaces the job definition of a running custodian.
+func (worker *RunCustodian) Update(job dtos.Job) {
+	worker.jobLock.Lock()
+	defer worker.jobLock.Unlock()
+	worker.Job = job
+}
+
 func (worker *RunCustodian) Stop() {
 	worker.stopOnce.Do(func() {
 		worker.isRunningLock.Lock()
@@ -68,7 +101,7 @@ func (worker *RunCustodian) Stop() {
 			return
 		}
 
-		log.Printf("Stopping run custodian for job %s...", worker.Job.Name)
+		log.Printf("Stopping run custodian for job %s...", worker.job().Name)
 		close(worker.quit)
 	})
 }
@@ -79,39 +112,78 @@ func (worker *RunCustodian) stopped() {
 	worker.isRunning = false
 }
 
+func (worker *RunCustodian) maxRestarts() int {
+	if worker.job().MaxRestarts <= 0 {
+		return defaultMaxRestarts
+	}
+
+	return worker.job().MaxRestarts
+}
+
+// restartStuckRuns publishes the run action again for runs that have not sent a
+// heartbeat within the job's heartbeat timeout or whose lease has expired. Runs
+// that have already been restarted the maximum number of times are failed
+// instead.
 func (worker *RunCustodian) restartStuckRuns() error {
-	if worker.Job.HeartbeatTimeout <= 0 {
-		return nil
+	job := worker.job()
+	runs, err := worker.leaseExpiredRuns()
+	if err != nil {
+		return err
 	}
 
-	runningStatus := runStatuses.Running
-	heartbeatBefore := time.Now().Add(time.Duration(-int(time.Millisecond) * worker.Job.HeartbeatTimeout))
-	filter := dtos.RunFilter{
-		JobName:         &worker.Job.Name,
-		Status:          &runningStatus,
-		HeartbeatBefore: &heartbeatBefore,
+	reasons := map[string]string{}
+	for _, run := range runs {
+		reasons[run.Id] = "lease expired"
 	}
-	runs, err := worker.RunRepo.Browse(filter)
-	if err != nil {
-		return fmt.Errorf("failed to get runs: %s", err)
+
+	if job.HeartbeatTimeout > 0 {
+		runningStatus := runStatuses.Running
+		heartbeatBefore := time.Now().Add(time.Duration(-int(time.Millisecond) * job.HeartbeatTimeout))
+		filter := dtos.RunFilter{
+			JobName:         &job.Name,
+			Status:          &runningStatus,
+			HeartbeatBefore: &heartbeatBefore,
+		}
+		heartbeatRuns, err := worker.RunRepo.Browse(filter)
+		if err != nil {
+			return fmt.Errorf("failed to get runs: %s", err)
+		}
+
+		for _, run := range heartbeatRuns {
+			if _, ok := reasons[run.Id]; !ok {
+				reasons[run.Id] = "heartbeat timeout"
+				runs = append(runs, run)
+			}
+		}
 	}
 
-	count := 0
+	restartCount := 0
+	failCount := 0
 	errs := []error{}
-	pendingStatus := runStatuses.Pending
 	for _, run := range runs {
-		runUpdate := dtos.RunUpdate{
-			Status: &pendingStatus,
+		if len(run.Restarts) >= worker.maxRestarts() {
+			if err := worker.failStuckRun(run, reasons[run.Id]); err != nil {
+				errs = append(errs, err)
+			} else {
+				failCount++
+			}
+
+			continue
 		}
-		if err := worker.RunRepo.Edit(run.Id, runUpdate); err != nil {
-			errs = append(errs, fmt.Errorf("failed to reset run %s: %s", run.Id, err))
+
+		if err := worker.restartRun(run, reasons[run.Id]); err != nil {
+			errs = append(errs, err)
 		} else {
-			count++
+			restartCount++
 		}
 	}
 
-	if count > 0 {
-		log.Printf("Reset %d stuck runs for job %s", count, worker.Job.Name)
+	if restartCount > 0 {
+		log.Printf("Restarted %d stuck runs for job %s", restartCount, job.Name)
+	}
+
+	if failCount > 0 {
+		log.Printf("Failed %d stuck runs for job %s that reached the maximum restarts", failCount, job.Name)
 	}
 
 	if len(errs) > 0 {
@@ -121,40 +193,161 @@ func (worker *RunCustodian) restartStuckRuns() error {
 	return nil
 }
 
-func (worker *RunCustodian) cancelRun(runId string) error {
+// leaseExpiredRuns returns the running runs of the job that were leased by a
+// worker through the API and have not had their lease renewed in time.
+func (worker *RunCustodian) leaseExpiredRuns() ([]dtos.Run, error) {
+	job := worker.job()
+	runningStatus := runStatuses.Running
+	now := time.Now()
+	filter := dtos.RunFilter{
+		JobName:            &job.Name,
+		Status:             &runningStatus,
+		LeaseExpiredBefore: &now,
+	}
+	runs, err := worker.RunRepo.Browse(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runs with expired leases: %s", err)
+	}
+
+	return runs, nil
+}
+
+func (worker *RunCustodian) restartRun(run dtos.Run, reason string) error {
+	now := time.Now()
+	pendingStatus := runStatuses.Pending
+	restart := dtos.RunRestart{
+		Time:          now,
+		LastHeartbeat: run.Heartbeat,
+		Reason:        reason,
+	}
+
+	action := dtos.JobActionMessage{
+		Action:     string(jobActions.Run),
+		Attempt:    run.Attempt,
+		Parameters: run.Parameters,
+		Restart:    len(run.Restarts) + 1,
+	}
+
+	// The heartbeat of a pending run is when it was last published, which the
+	// run start timeout is measured from. The lease is released so the run can
+	// be leased again, and the sequence and message IDs are cleared so that the
+	// client can number the messages of the restart from the start. The worker
+	// is cleared so that actions are not sent to the one that stopped.
+	runUpdate := dtos.RunUpdate{
+		Status:        &pendingStatus,
+		Heartbeat:     &now,
+		Restart:       &restart,
+		Outbox:        outbox.NewJobMessage(worker.job(), action, now),
+		ClearLease:    true,
+		ClearMessages: true,
+		ClearWorker:   true,
+	}
+	if err := worker.RunRepo.Edit(run.Id, runUpdate); err != nil {
+		return fmt.Errorf("failed to reset run %s: %s", run.Id, err)
+	}
+
+	worker.dispatchRun(run.Id)
+	return nil
+}
+
+func (worker *RunCustodian) failStuckRun(run dtos.Run, reason string) error {
+	now := time.Now()
+	failedStatus := runStatuses.Failed
+	reason = fmt.Sprintf("%s after %d restarts", reason, len(run.Restarts))
+	runUpdate := dtos.RunUpdate{
+		Status:  &failedStatus,
+		EndTime: &now,
+		Reason:  &reason,
+	}
+	if err := worker.RunRepo.Edit(run.Id, runUpdate); err != nil {
+		return fmt.Errorf("failed to fail run %s: %s", run.Id, err)
+	}
+
+	worker.runFinished(run.Id, failedStatus)
+	return nil
+}
+
+func (worker *RunCustodian) runFinished(runId string, status runStatuses.RunStatus) {
+	if worker.RunFinished != nil {
+		worker.RunFinished(runId, status)
+	}
+}
+
+// dispatchRun publishes the actions stored with a run straight away. Actions
+// that fail to publish are retried by the job worker's dispatch of the outbox.
+func (worker *RunCustodian) dispatchRun(runId string) {
+	if err := worker.Dispatcher.DispatchRun(runId); err != nil {
+		log.Printf("Failed to dispatch actions of run %s for job %s, will retry: %s", runId, worker.job().Name, err)
+	}
+}
+
+func (worker *RunCustodian) cancelRun(runId string, reason string) error {
 	cancellingStatus := runStatuses.Cancelling
+	timedOut := true
+	now := time.Now()
 	runUpdate := dtos.RunUpdate{
-		Status: &cancellingStatus,
+		Status:              &cancellingStatus,
+		Reason:              &reason,
+		TimedOut:            &timedOut,
+		CancelRequestedTime: &now,
+		Outbox: outbox.NewJobMessage(worker.job(), dtos.JobActionMessage{
+			Action: string(jobActions.Cancel),
+			Reason: reason,
+		}, now),
 	}
 	if err := worker.RunRepo.Edit(runId, runUpdate); err != nil {
 		return fmt.Errorf("failed to cancel run %s: %s", runId, err)
 	}
 
-	body, err := json.Marshal(dtos.JobActionMessage{
-		RunId:  runId,
-		Action: string(jobActions.Cancel),
-	})
-	if err != nil {
-		return fmt.Errorf("failed to serialize run action %s: %s", runId, err)
+	worker.dispatchRun(runId)
+	return nil
+}
+
+// failRun fails a run that timed out without waiting for the client. Statuses
+// the client sends for the run afterwards are rejected.
+func (worker *RunCustodian) failRun(runId string, reason string) error {
+	failedStatus := runStatuses.Failed
+	timedOut := true
+	now := time.Now()
+	runUpdate := dtos.RunUpdate{
+		Status:   &failedStatus,
+		EndTime:  &now,
+		Reason:   &reason,
+		TimedOut: &timedOut,
+	}
+	if err := worker.RunRepo.Edit(runId, runUpdate); err != nil {
+		return fmt.Errorf("failed to fail run %s: %s", runId, err)
 	}
 
-	err = worker.MessageBus.Publish(
-		"scheduler.job."+worker.Job.Name,
-		"action",
-		body,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to publish cancel action for run %s: %s", runId, err)
+	worker.runFinished(runId, failedStatus)
+	return nil
+}
+
+// notifyRun marks a run as timed out and publishes a timeout action, leaving
+// the client to decide whether to stop it.
+func (worker *RunCustodian) notifyRun(runId string, reason string) error {
+	timedOut := true
+	runUpdate := dtos.RunUpdate{
+		Reason:   &reason,
+		TimedOut: &timedOut,
+		Outbox: outbox.NewJobMessage(worker.job(), dtos.JobActionMessage{
+			Action: string(jobActions.Timeout),
+			Reason: reason,
+		}, time.Now()),
+	}
+	if err := worker.RunRepo.Edit(runId, runUpdate); err != nil {
+		return fmt.Errorf("failed to mark run %s as timed out: %s", runId, err)
 	}
 
+	worker.dispatchRun(runId)
 	return nil
 }
 
-func (worker *RunCustodian) cancelRuns(runs []dtos.Run, reason string) error {
+func (worker *RunCustodian) applyToRuns(runs []dtos.Run, reason string, verb string, apply func(runId string, reason string) error) error {
 	count := 0
 	errs := []error{}
 	for _, run := range runs {
-		if err := worker.cancelRun(run.Id); err != nil {
+		if err := apply(run.Id, reason); err != nil {
 			errs = append(errs, err)
 		} else {
 			count++
@@ -162,7 +355,7 @@ func (worker *RunCustodian) cancelRuns(runs []dtos.Run, reason string) error {
 	}
 
 	if count > 0 {
-		log.Printf("Cancelled %d runs for job %s because of %s", count, worker.Job.Name, reason)
+		log.Printf("%s %d runs for job %s because of %s", verb, count, worker.job().Name, reason)
 	}
 
 	if len(errs) > 0 {
@@ -172,17 +365,22 @@ func (worker *RunCustodian) cancelRuns(runs []dtos.Run, reason string) error {
 	return nil
 }
 
+func (worker *RunCustodian) cancelRuns(runs []dtos.Run, reason string) error {
+	return worker.applyToRuns(runs, reason, "Cancelled", worker.cancelRun)
+}
+
 func (worker *RunCustodian) cancelTimeoutPendingRuns() error {
-	if worker.Job.RunStartTimeout <= 0 {
+	job := worker.job()
+	if job.RunStartTimeout <= 0 {
 		return nil
 	}
 
 	pendingStatus := runStatuses.Pending
-	createdBefore := time.Now().Add(time.Duration(-int(time.Millisecond) * worker.Job.RunStartTimeout))
+	publishedBefore := time.Now().Add(time.Duration(-int(time.Millisecond) * job.RunStartTimeout))
 	filter := dtos.RunFilter{
-		JobName:       &worker.Job.Name,
-		Status:        &pendingStatus,
-		CreatedBefore: &createdBefore,
+		JobName:         &job.Name,
+		Status:          &pendingStatus,
+		HeartbeatBefore: &publishedBefore,
 	}
 	runs, err := worker.RunRepo.Browse(filter)
 	if err != nil {
@@ -192,15 +390,64 @@ func (worker *RunCustodian) cancelTimeoutPendingRuns() error {
 	return worker.cancelRuns(runs, "run start timeout")
 }
 
-func (worker *RunCustodian) cancelTimeoutRunningRuns() error {
-	if worker.Job.RunExecutionTimeout <= 0 {
+func (worker *RunCustodian) cancelTimeoutQueuedRuns() error {
+	job := worker.job()
+	if job.RunStartTimeout <= 0 {
+		return nil
+	}
+
+	createdBefore := time.Now().Add(time.Duration(-int(time.Millisecond) * job.RunStartTimeout))
+	filter := dtos.RunFilter{
+		JobName:       &job.Name,
+		Statuses:      []runStatuses.RunStatus{runStatuses.Requested, runStatuses.Queued},
+		CreatedBefore: &createdBefore,
+	}
+	runs, err := worker.RunRepo.Browse(filter)
+	if err != nil {
+		return fmt.Errorf("failed to get runs: %s", err)
+	}
+
+	// These runs were never published so they are cancelled without a runner
+	count := 0
+	errs := []error{}
+	cancelledStatus := runStatuses.Cancelled
+	for _, run := range runs {
+		endTime := time.Now()
+		runUpdate := dtos.RunUpdate{
+			Status:  &cancelledStatus,
+			EndTime: &endTime,
+		}
+		if err := worker.RunRepo.Edit(run.Id, runUpdate); err != nil {
+			errs = append(errs, fmt.Errorf("failed to cancel run %s: %s", run.Id, err))
+		} else {
+			worker.runFinished(run.Id, cancelledStatus)
+			count++
+		}
+	}
+
+	if count > 0 {
+		log.Printf("Cancelled %d queued runs for job %s because of run start timeout", count, job.Name)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// timeoutRunningRuns applies the job's execution timeout action to runs that
+// have been running for longer than its run execution timeout.
+func (worker *RunCustodian) timeoutRunningRuns() error {
+	job := worker.job()
+	if job.RunExecutionTimeout <= 0 {
 		return nil
 	}
 
 	runningStatus := runStatuses.Running
-	startedBefore := time.Now().Add(time.Duration(-int(time.Millisecond) * worker.Job.RunExecutionTimeout))
+	startedBefore := time.Now().Add(time.Duration(-int(time.Millisecond) * job.RunExecutionTimeout))
 	filter := dtos.RunFilter{
-		JobName:       &worker.Job.Name,
+		JobName:       &job.Name,
 		Status:        &runningStatus,
 		StartedBefore: &startedBefore,
 	}
@@ -209,15 +456,111 @@ func (worker *RunCustodian) cancelTimeoutRunningRuns() error {
 		return fmt.Errorf("failed to get runs: %s", err)
 	}
 
-	return worker.cancelRuns(runs, "run execution timeout")
+	reason := "run execution timeout"
+	switch timeoutActions.TimeoutAction(job.ExecutionTimeoutAction) {
+	case timeoutActions.Fail:
+		return worker.applyToRuns(runs, reason, "Failed", worker.failRun)
+	case timeoutActions.Notify:
+		// Runs are only notified once
+		runs = slices.DeleteFunc(runs, func(run dtos.Run) bool {
+			return run.TimedOut
+		})
+		return worker.applyToRuns(runs, reason, "Notified", worker.notifyRun)
+	default:
+		return worker.cancelRuns(runs, reason)
+	}
+}
+
+// forceCancellingRuns moves runs that have been cancelling for longer than the
+// job's cancel grace period to cancelled, for when the client is not able to
+// respond to the cancel action.
+func (worker *RunCustodian) forceCancellingRuns() error {
+	job := worker.job()
+	gracePeriod := time.Duration(int(time.Millisecond) * job.CancelGracePeriod)
+	if gracePeriod <= 0 {
+		gracePeriod = worker.CancelGracePeriod
+	}
+	if gracePeriod <= 0 {
+		gracePeriod = defaultCancelGracePeriod
+	}
+
+	cancellingStatus := runStatuses.Cancelling
+	requestedBefore := time.Now().Add(-gracePeriod)
+	filter := dtos.RunFilter{
+		JobName:               &job.Name,
+		Status:                &cancellingStatus,
+		CancelRequestedBefore: &requestedBefore,
+	}
+	runs, err := worker.RunRepo.Browse(filter)
+	if err != nil {
+		return fmt.Errorf("failed to get runs: %s", err)
+	}
+
+	count := 0
+	errs := []error{}
+	cancelledStatus := runStatuses.Cancelled
+	forced := true
+	for _, run := range runs {
+		endTime := time.Now()
+		runUpdate := dtos.RunUpdate{
+			Status:  &cancelledStatus,
+			EndTime: &endTime,
+			Forced:  &forced,
+		}
+		if err := worker.RunRepo.Edit(run.Id, runUpdate); err != nil {
+			errs = append(errs, fmt.Errorf("failed to force cancel run %s: %s", run.Id, err))
+		} else {
+			worker.runFinished(run.Id, cancelledStatus)
+			count++
+		}
+	}
+
+	if count > 0 {
+		log.Printf("Forced %d cancelling runs for job %s to cancelled because of cancel grace period", count, job.Name)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// deleteExpiredLogs deletes the logs of the job that are older than the run log
+// retention.
+func (worker *RunCustodian) deleteExpiredLogs() error {
+	job := worker.job()
+	retention := worker.RunLogRetention
+	if retention <= 0 {
+		retention = defaultRunLogRetention
+	}
+
+	loggedBefore := time.Now().Add(-retention)
+	filter := dtos.RunLogFilter{
+		JobName:      &job.Name,
+		LoggedBefore: &loggedBefore,
+	}
+	count, err := worker.RunLogRepo.Delete(filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete expired logs: %s", err)
+	}
+
+	if count > 0 {
+		log.Printf("Deleted %d expired log lines for job %s", count, job.Name)
+	}
+
+	return nil
 }
 
 func (worker *RunCustodian) clean() error {
 	restartErr := worker.restartStuckRuns()
 	pendingErr := worker.cancelTimeoutPendingRuns()
-	runningErr := worker.cancelTimeoutRunningRuns()
+	queuedErr := worker.cancelTimeoutQueuedRuns()
+	runningErr := worker.timeoutRunningRuns()
+	cancellingErr := worker.forceCancellingRuns()
+	logsErr := worker.deleteExpiredLogs()
 
-	return errors.Join(restartErr, pendingErr, runningErr)
+	return errors.Join(restartErr, pendingErr, queuedErr, runningErr, cancellingErr, logsErr)
 }
 
 func (worker *RunCustodian) process(wg *sync.WaitGroup) {
@@ -230,12 +573,12 @@ func (worker *RunCustodian) process(wg *sync.WaitGroup) {
 	for {
 		select {
 		case <-worker.quit:
-			log.Printf("Stopped run custodian for job %s", worker.Job.Name)
+			log.Printf("Stopped run custodian for job %s", worker.job().Name)
 			worker.stopped()
 			return
 		case <-ticker.C:
 			if err := worker.clean(); err != nil {
-				log.Printf("Failed to clean runs for job %s: %s", worker.Job.Name, err)
+				log.Printf("Failed to clean runs for job %s: %s", worker.job().Name, err)
 			}
 		}
 	}
@@ -0,0 +1,148 @@
+package workers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
+	repositoryErrors "github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories/errors"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	messageBus "github.com/jacobmcgowan/simple-scheduler/shared/message-bus"
+)
+
+const defaultWorkerHeartbeatInterval = time.Second * 10
+
+// A worker is removed from the registry once it has missed this many
+// heartbeats.
+const maxMissedWorkerHeartbeats = 3
+
+// WorkerRegistry keeps track of the workers performing runs from the heartbeat
+// messages they publish. Workers announce themselves with a heartbeat when
+// they start and unregister when they stop.
+type WorkerRegistry struct {
+	MessageBus      messageBus.MessageBus
+	WorkerRepo      repositories.WorkerRepository
+	isRunningLock   sync.Mutex `default:"sync.Mutex{}"`
+	isRunning       bool
+	heartbeatQueue  string
+	unregisterQueue string
+}
+
+func (registry *WorkerRegistry) Start(wg *sync.WaitGroup) error {
+	registry.isRunningLock.Lock()
+	defer registry.isRunningLock.Unlock()
+
+	if registry.isRunning {
+		return nil
+	}
+
+	log.Printf("Starting worker registry...")
+	registry.heartbeatQueue = messageBus.WorkersExchange + ".heartbeat"
+	registry.unregisterQueue = messageBus.WorkersExchange + ".unregister"
+	err := registry.MessageBus.Register(
+		messageBus.WorkersExchange,
+		map[string][]string{
+			registry.heartbeatQueue:  {"heartbeat"},
+			registry.unregisterQueue: {"unregister"},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register workers to message bus: %s", err)
+	}
+
+	if err = registry.MessageBus.Subscribe(wg, registry.heartbeatQueue, registry.heartbeatMessageReceived); err != nil {
+		return fmt.Errorf("failed to subscribe to worker heartbeat queue: %s", err)
+	}
+
+	if err = registry.MessageBus.Subscribe(wg, registry.unregisterQueue, registry.unregisterMessageReceived); err != nil {
+		registry.MessageBus.Unsubscribe(registry.heartbeatQueue)
+		return fmt.Errorf("failed to subscribe to worker unregister queue: %s", err)
+	}
+
+	registry.isRunning = true
+	log.Printf("Started worker registry")
+	return nil
+}
+
+func (registry *WorkerRegistry) Stop() {
+	registry.isRunningLock.Lock()
+	defer registry.isRunningLock.Unlock()
+
+	if !registry.isRunning {
+		return
+	}
+
+	log.Printf("Stopping worker registry...")
+	registry.MessageBus.Unsubscribe(registry.heartbeatQueue)
+	registry.MessageBus.Unsubscribe(registry.unregisterQueue)
+	registry.isRunning = false
+	log.Printf("Stopped worker registry")
+}
+
+func (registry *WorkerRegistry) heartbeatMessageReceived(body []byte) (error, bool) {
+	msg, err := parseWorkerMessage(body)
+	if err != nil {
+		return err, false
+	}
+
+	heartbeatInterval := defaultWorkerHeartbeatInterval
+	if msg.HeartbeatInterval > 0 {
+		heartbeatInterval = time.Duration(msg.HeartbeatInterval) * time.Millisecond
+	}
+
+	now := time.Now()
+	worker := dtos.Worker{
+		Id:             msg.WorkerId,
+		Hostname:       msg.Hostname,
+		JobNames:       msg.JobNames,
+		Pool:           msg.Pool,
+		Tags:           msg.Tags,
+		Capacity:       msg.Capacity,
+		ActiveRuns:     msg.ActiveRuns,
+		Version:        msg.Version,
+		RegisteredTime: now,
+		Heartbeat:      now,
+		ExpiresTime:    now.Add(heartbeatInterval * maxMissedWorkerHeartbeats),
+	}
+	if err := registry.WorkerRepo.Register(worker); err != nil {
+		return fmt.Errorf("failed to register worker %s: %s", msg.WorkerId, err), true
+	}
+
+	return nil, false
+}
+
+func (registry *WorkerRegistry) unregisterMessageReceived(body []byte) (error, bool) {
+	msg, err := parseWorkerMessage(body)
+	if err != nil {
+		return err, false
+	}
+
+	if err := registry.WorkerRepo.Delete(msg.WorkerId); err != nil {
+		var notFoundErr *repositoryErrors.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil, false
+		}
+
+		return fmt.Errorf("failed to unregister worker %s: %s", msg.WorkerId, err), true
+	}
+
+	log.Printf("Unregistered worker %s@%s", msg.WorkerId, msg.Hostname)
+	return nil, false
+}
+
+func parseWorkerMessage(body []byte) (dtos.WorkerMessage, error) {
+	var msg dtos.WorkerMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return msg, fmt.Errorf("failed to deserialize worker message: %s", err)
+	}
+
+	if msg.WorkerId == "" {
+		return msg, fmt.Errorf("worker message is missing the worker ID: %s", body)
+	}
+
+	return msg, nil
+}
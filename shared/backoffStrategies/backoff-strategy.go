@@ -0,0 +1,8 @@
+package backoffStrategies
+
+type BackoffStrategy string
+
+const (
+	Fixed       BackoffStrategy = "fixed"
+	Exponential BackoffStrategy = "exponential"
+)
@@ -0,0 +1,8 @@
+package concurrencyPolicies
+
+type ConcurrencyPolicy string
+
+const (
+	Skip  ConcurrencyPolicy = "skip"
+	Queue ConcurrencyPolicy = "queue"
+)
@@ -10,11 +10,31 @@ func JobUpdateFromDto(dto dtos.JobUpdate) bson.D {
 	setDoc = AppendBson(setDoc, "enabled", dto.Enabled)
 	setDoc = AppendBson(setDoc, "nextRunAt", dto.NextRunAt)
 	setDoc = AppendBson(setDoc, "interval", dto.Interval)
+	setDoc = AppendBson(setDoc, "schedule", dto.Schedule)
+	setDoc = AppendBson(setDoc, "dependsOn", dto.DependsOn)
+	setDoc = AppendBson(setDoc, "timeZone", dto.TimeZone)
 	setDoc = AppendBson(setDoc, "runExecutionTimeout", dto.RunExecutionTimeout)
 	setDoc = AppendBson(setDoc, "runStartTimeout", dto.RunStartTimeout)
+	setDoc = AppendBson(setDoc, "executionTimeoutAction", dto.ExecutionTimeoutAction)
+	setDoc = AppendBson(setDoc, "cancelGracePeriod", dto.CancelGracePeriod)
 	setDoc = AppendBson(setDoc, "maxQueueCount", dto.MaxQueueCount)
 	setDoc = AppendBson(setDoc, "allowConcurrentRuns", dto.AllowConcurrentRuns)
+	setDoc = AppendBson(setDoc, "concurrencyPolicy", dto.ConcurrencyPolicy)
+	setDoc = AppendBson(setDoc, "misfirePolicy", dto.MisfirePolicy)
+	setDoc = AppendBson(setDoc, "misfireThreshold", dto.MisfireThreshold)
+	setDoc = AppendBson(setDoc, "parameters", dto.Parameters)
+	if dto.RetryPolicy != nil {
+		setDoc = AppendBson(setDoc, "retryPolicy.maxAttempts", dto.RetryPolicy.MaxAttempts)
+		setDoc = AppendBson(setDoc, "retryPolicy.backoff", dto.RetryPolicy.Backoff)
+		setDoc = AppendBson(setDoc, "retryPolicy.delay", dto.RetryPolicy.Delay)
+		setDoc = AppendBson(setDoc, "retryPolicy.maxDelay", dto.RetryPolicy.MaxDelay)
+		setDoc = AppendBson(setDoc, "retryPolicy.retryOnTimeout", dto.RetryPolicy.RetryOnTimeout)
+	}
 	setDoc = AppendBson(setDoc, "heartbeatTimeout", dto.HeartbeatTimeout)
+	setDoc = AppendBson(setDoc, "maxRestarts", dto.MaxRestarts)
+	setDoc = AppendBson(setDoc, "workerPool", dto.WorkerPool)
+	setDoc = AppendBson(setDoc, "workerTags", dto.WorkerTags)
+	setDoc = AppendBson(setDoc, "dispatchMode", dto.DispatchMode)
 
 	return bson.D{{
 		Key:   "$set",
@@ -8,32 +8,60 @@ import (
 )
 
 type Job struct {
-	Name                string        `bson:"_id"`
-	Enabled             bool          `bson:"enabled"`
-	NextRunAt           time.Time     `bson:"nextRunAt"`
-	Interval            int           `bson:"interval"`
-	RunExecutionTimeout int           `bson:"runExecutionTimeout"`
-	RunStartTimeout     int           `bson:"runStartTimeout"`
-	MaxQueueCount       int           `bson:"maxQueueCount"`
-	AllowConcurrentRuns bool          `bson:"allowConcurrentRuns"`
-	HeartbeatTimeout    int           `bson:"heartbeatTimeout"`
-	ManagerId           bson.ObjectID `bson:"managerId,omitempty"`
-	Heartbeat           time.Time     `bson:"heartbeat"`
+	Name                   string        `bson:"_id"`
+	Enabled                bool          `bson:"enabled"`
+	NextRunAt              time.Time     `bson:"nextRunAt"`
+	Interval               int           `bson:"interval"`
+	Schedule               string        `bson:"schedule,omitempty"`
+	DependsOn              []string      `bson:"dependsOn,omitempty"`
+	TimeZone               string        `bson:"timeZone,omitempty"`
+	RunExecutionTimeout    int           `bson:"runExecutionTimeout"`
+	RunStartTimeout        int           `bson:"runStartTimeout"`
+	ExecutionTimeoutAction string        `bson:"executionTimeoutAction,omitempty"`
+	CancelGracePeriod      int           `bson:"cancelGracePeriod"`
+	MaxQueueCount          int           `bson:"maxQueueCount"`
+	AllowConcurrentRuns    bool          `bson:"allowConcurrentRuns"`
+	ConcurrencyPolicy      string        `bson:"concurrencyPolicy,omitempty"`
+	MisfirePolicy          string        `bson:"misfirePolicy,omitempty"`
+	MisfireThreshold       int           `bson:"misfireThreshold"`
+	RetryPolicy            RetryPolicy   `bson:"retryPolicy"`
+	Parameters             bson.M        `bson:"parameters,omitempty"`
+	HeartbeatTimeout       int           `bson:"heartbeatTimeout"`
+	MaxRestarts            int           `bson:"maxRestarts"`
+	WorkerPool             string        `bson:"workerPool,omitempty"`
+	WorkerTags             []string      `bson:"workerTags,omitempty"`
+	DispatchMode           string        `bson:"dispatchMode,omitempty"`
+	ManagerId              bson.ObjectID `bson:"managerId,omitempty"`
+	Heartbeat              time.Time     `bson:"heartbeat"`
 }
 
 func (job Job) ToDto() dtos.Job {
 	return dtos.Job{
-		Name:                job.Name,
-		Enabled:             job.Enabled,
-		NextRunAt:           job.NextRunAt,
-		Interval:            job.Interval,
-		RunExecutionTimeout: job.RunExecutionTimeout,
-		RunStartTimeout:     job.RunStartTimeout,
-		MaxQueueCount:       job.MaxQueueCount,
-		AllowConcurrentRuns: job.AllowConcurrentRuns,
-		HeartbeatTimeout:    job.HeartbeatTimeout,
-		ManagerId:           job.ManagerId.Hex(),
-		Heartbeat:           job.Heartbeat,
+		Name:                   job.Name,
+		Enabled:                job.Enabled,
+		NextRunAt:              job.NextRunAt,
+		Interval:               job.Interval,
+		Schedule:               job.Schedule,
+		DependsOn:              job.DependsOn,
+		TimeZone:               job.TimeZone,
+		RunExecutionTimeout:    job.RunExecutionTimeout,
+		RunStartTimeout:        job.RunStartTimeout,
+		ExecutionTimeoutAction: job.ExecutionTimeoutAction,
+		CancelGracePeriod:      job.CancelGracePeriod,
+		MaxQueueCount:          job.MaxQueueCount,
+		AllowConcurrentRuns:    job.AllowConcurrentRuns,
+		ConcurrencyPolicy:      job.ConcurrencyPolicy,
+		MisfirePolicy:          job.MisfirePolicy,
+		MisfireThreshold:       job.MisfireThreshold,
+		RetryPolicy:            job.RetryPolicy.ToDto(),
+		Parameters:             job.Parameters,
+		HeartbeatTimeout:       job.HeartbeatTimeout,
+		MaxRestarts:            job.MaxRestarts,
+		WorkerPool:             job.WorkerPool,
+		WorkerTags:             job.WorkerTags,
+		DispatchMode:           job.DispatchMode,
+		ManagerId:              job.ManagerId.Hex(),
+		Heartbeat:              job.Heartbeat,
 	}
 }
 
@@ -42,11 +70,25 @@ func (job *Job) FromDto(dto dtos.Job) {
 	job.Enabled = dto.Enabled
 	job.NextRunAt = dto.NextRunAt
 	job.Interval = dto.Interval
+	job.Schedule = dto.Schedule
+	job.DependsOn = dto.DependsOn
+	job.TimeZone = dto.TimeZone
 	job.RunExecutionTimeout = dto.RunExecutionTimeout
 	job.RunStartTimeout = dto.RunStartTimeout
+	job.ExecutionTimeoutAction = dto.ExecutionTimeoutAction
+	job.CancelGracePeriod = dto.CancelGracePeriod
 	job.MaxQueueCount = dto.MaxQueueCount
 	job.AllowConcurrentRuns = dto.AllowConcurrentRuns
+	job.ConcurrencyPolicy = dto.ConcurrencyPolicy
+	job.MisfirePolicy = dto.MisfirePolicy
+	job.MisfireThreshold = dto.MisfireThreshold
+	job.RetryPolicy.FromDto(dto.RetryPolicy)
+	job.Parameters = dto.Parameters
 	job.HeartbeatTimeout = dto.HeartbeatTimeout
+	job.MaxRestarts = dto.MaxRestarts
+	job.WorkerPool = dto.WorkerPool
+	job.WorkerTags = dto.WorkerTags
+	job.DispatchMode = dto.DispatchMode
 	job.Heartbeat = dto.Heartbeat
 
 	mngrId, err := bson.ObjectIDFromHex(dto.ManagerId)
@@ -0,0 +1,24 @@
+package mongoModels
+
+import (
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// OutboxMessageUpdateFromDto updates the message matched by the positional
+// operator so the filter must include the message's ID.
+func OutboxMessageUpdateFromDto(dto dtos.OutboxMessageUpdate) bson.D {
+	setDoc := bson.D{}
+	setDoc = AppendBson(setDoc, "outbox.$.sent", dto.Sent)
+	setDoc = AppendBson(setDoc, "outbox.$.sentTime", dto.SentTime)
+	setDoc = AppendBson(setDoc, "outbox.$.dispatchTime", dto.DispatchTime)
+	setDoc = AppendBson(setDoc, "outbox.$.attempts", dto.Attempts)
+	setDoc = AppendBson(setDoc, "outbox.$.error", dto.Error)
+	setDoc = AppendBson(setDoc, "outbox.$.discarded", dto.Discarded)
+	setDoc = AppendBson(setDoc, "outbox.$.claimedUntil", dto.ClaimedUntil)
+
+	return bson.D{{
+		Key:   "$set",
+		Value: setDoc,
+	}}
+}
@@ -0,0 +1,79 @@
+package mongoModels
+
+import (
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// The job name and run ID of an action are those of the run storing it so
+// they are not duplicated in the outbox.
+type OutboxAction struct {
+	Action     string `bson:"action"`
+	Attempt    int    `bson:"attempt,omitempty"`
+	Parameters bson.M `bson:"parameters,omitempty"`
+	Reason     string `bson:"reason,omitempty"`
+	Restart    int    `bson:"restart,omitempty"`
+}
+
+type OutboxMessage struct {
+	Id           bson.ObjectID `bson:"id"`
+	Action       OutboxAction  `bson:"action"`
+	CreatedTime  time.Time     `bson:"createdTime"`
+	DispatchTime time.Time     `bson:"dispatchTime"`
+	Sent         bool          `bson:"sent"`
+	SentTime     time.Time     `bson:"sentTime,omitempty"`
+	Attempts     int           `bson:"attempts"`
+	Error        string        `bson:"error,omitempty"`
+	Discarded    bool          `bson:"discarded,omitempty"`
+	ClaimedUntil time.Time     `bson:"claimedUntil,omitempty"`
+}
+
+func (message OutboxMessage) ToDto(jobName string, runId string) dtos.OutboxMessage {
+	return dtos.OutboxMessage{
+		Id: message.Id.Hex(),
+		Action: dtos.JobActionMessage{
+			JobName:    jobName,
+			RunId:      runId,
+			Action:     message.Action.Action,
+			Attempt:    message.Action.Attempt,
+			Parameters: message.Action.Parameters,
+			Reason:     message.Action.Reason,
+			Restart:    message.Action.Restart,
+		},
+		CreatedTime:  message.CreatedTime,
+		DispatchTime: message.DispatchTime,
+		Sent:         message.Sent,
+		SentTime:     message.SentTime,
+		Attempts:     message.Attempts,
+		Error:        message.Error,
+		Discarded:    message.Discarded,
+		ClaimedUntil: message.ClaimedUntil,
+	}
+}
+
+// FromDto generates an ID for new messages, which are added without one.
+func (message *OutboxMessage) FromDto(dto dtos.OutboxMessage) {
+	id, err := bson.ObjectIDFromHex(dto.Id)
+	if err != nil {
+		id = bson.NewObjectID()
+	}
+
+	message.Id = id
+	message.Action = OutboxAction{
+		Action:     dto.Action.Action,
+		Attempt:    dto.Action.Attempt,
+		Parameters: dto.Action.Parameters,
+		Reason:     dto.Action.Reason,
+		Restart:    dto.Action.Restart,
+	}
+	message.CreatedTime = dto.CreatedTime
+	message.DispatchTime = dto.DispatchTime
+	message.Sent = dto.Sent
+	message.SentTime = dto.SentTime
+	message.Attempts = dto.Attempts
+	message.Error = dto.Error
+	message.Discarded = dto.Discarded
+	message.ClaimedUntil = dto.ClaimedUntil
+}
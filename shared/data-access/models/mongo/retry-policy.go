@@ -0,0 +1,29 @@
+package mongoModels
+
+import "github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+
+type RetryPolicy struct {
+	MaxAttempts    int    `bson:"maxAttempts"`
+	Backoff        string `bson:"backoff,omitempty"`
+	Delay          int    `bson:"delay"`
+	MaxDelay       int    `bson:"maxDelay"`
+	RetryOnTimeout bool   `bson:"retryOnTimeout"`
+}
+
+func (policy RetryPolicy) ToDto() dtos.RetryPolicy {
+	return dtos.RetryPolicy{
+		MaxAttempts:    policy.MaxAttempts,
+		Backoff:        policy.Backoff,
+		Delay:          policy.Delay,
+		MaxDelay:       policy.MaxDelay,
+		RetryOnTimeout: policy.RetryOnTimeout,
+	}
+}
+
+func (policy *RetryPolicy) FromDto(dto dtos.RetryPolicy) {
+	policy.MaxAttempts = dto.MaxAttempts
+	policy.Backoff = dto.Backoff
+	policy.Delay = dto.Delay
+	policy.MaxDelay = dto.MaxDelay
+	policy.RetryOnTimeout = dto.RetryOnTimeout
+}
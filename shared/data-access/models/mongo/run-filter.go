@@ -9,9 +9,35 @@ func RunFilterFromDto(dto dtos.RunFilter) bson.D {
 	filter := bson.D{}
 	filter = AppendBsonCondition(filter, "jobName", "$eq", dto.JobName)
 	filter = AppendBsonCondition(filter, "status", "$eq", dto.Status)
+	filter = AppendBsonCondition(filter, "period", "$eq", dto.Period)
 	filter = AppendBsonCondition(filter, "createdTime", "$lt", dto.CreatedBefore)
 	filter = AppendBsonCondition(filter, "startTime", "$lt", dto.StartedBefore)
 	filter = AppendBsonCondition(filter, "heartbeat", "$lt", dto.HeartbeatBefore)
+	filter = AppendBsonCondition(filter, "cancelRequestedTime", "$lt", dto.CancelRequestedBefore)
+	filter = AppendBsonCondition(filter, "lease.expiresTime", "$lt", dto.LeaseExpiredBefore)
+	filter = AppendBsonCondition(filter, "reported", "$eq", dto.Reported)
+
+	if dto.OutboxDueBefore != nil {
+		filter = append(filter, bson.E{
+			Key: "outbox",
+			Value: bson.M{
+				"$elemMatch": bson.M{
+					"sent":         false,
+					"discarded":    bson.M{"$ne": true},
+					"dispatchTime": bson.M{"$lte": dto.OutboxDueBefore},
+				},
+			},
+		})
+	}
+
+	if len(dto.Statuses) > 0 {
+		filter = append(filter, bson.E{
+			Key: "status",
+			Value: bson.M{
+				"$in": dto.Statuses,
+			},
+		})
+	}
 
 	return filter
 }
@@ -0,0 +1,27 @@
+package mongoModels
+
+import (
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+)
+
+type RunLease struct {
+	WorkerId    string    `bson:"workerId"`
+	LeasedTime  time.Time `bson:"leasedTime"`
+	ExpiresTime time.Time `bson:"expiresTime"`
+}
+
+func (lease RunLease) ToDto() dtos.RunLease {
+	return dtos.RunLease{
+		WorkerId:    lease.WorkerId,
+		LeasedTime:  lease.LeasedTime,
+		ExpiresTime: lease.ExpiresTime,
+	}
+}
+
+func (lease *RunLease) FromDto(dto dtos.RunLease) {
+	lease.WorkerId = dto.WorkerId
+	lease.LeasedTime = dto.LeasedTime
+	lease.ExpiresTime = dto.ExpiresTime
+}
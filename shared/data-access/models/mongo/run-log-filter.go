@@ -0,0 +1,28 @@
+package mongoModels
+
+import (
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// RunLogFilterFromDto ignores run and log IDs that are not valid object IDs,
+// which the repository reports before building the filter.
+func RunLogFilterFromDto(dto dtos.RunLogFilter) bson.D {
+	filter := bson.D{}
+	filter = AppendBsonCondition(filter, "jobName", "$eq", dto.JobName)
+	filter = AppendBsonCondition(filter, "time", "$lt", dto.LoggedBefore)
+
+	if dto.RunId != nil {
+		if runId, err := bson.ObjectIDFromHex(*dto.RunId); err == nil {
+			filter = AppendBsonCondition(filter, "runId", "$eq", &runId)
+		}
+	}
+
+	if dto.After != nil {
+		if after, err := bson.ObjectIDFromHex(*dto.After); err == nil {
+			filter = AppendBsonCondition(filter, "_id", "$gt", &after)
+		}
+	}
+
+	return filter
+}
@@ -0,0 +1,47 @@
+package mongoModels
+
+import (
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type RunLog struct {
+	Id      bson.ObjectID `bson:"_id,omitempty"`
+	JobName string        `bson:"jobName"`
+	RunId   bson.ObjectID `bson:"runId"`
+	Time    time.Time     `bson:"time"`
+	Level   string        `bson:"level,omitempty"`
+	Message string        `bson:"message"`
+}
+
+func (runLog RunLog) ToDto() dtos.RunLog {
+	return dtos.RunLog{
+		Id:      runLog.Id.Hex(),
+		JobName: runLog.JobName,
+		RunId:   runLog.RunId.Hex(),
+		Time:    runLog.Time,
+		Level:   runLog.Level,
+		Message: runLog.Message,
+	}
+}
+
+func (runLog *RunLog) FromDto(dto dtos.RunLog) {
+	id, err := bson.ObjectIDFromHex(dto.Id)
+	if err != nil {
+		id = bson.NilObjectID
+	}
+
+	runId, err := bson.ObjectIDFromHex(dto.RunId)
+	if err != nil {
+		runId = bson.NilObjectID
+	}
+
+	runLog.Id = id
+	runLog.JobName = dto.JobName
+	runLog.RunId = runId
+	runLog.Time = dto.Time
+	runLog.Level = dto.Level
+	runLog.Message = dto.Message
+}
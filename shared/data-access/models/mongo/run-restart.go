@@ -0,0 +1,27 @@
+package mongoModels
+
+import (
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+)
+
+type RunRestart struct {
+	Time          time.Time `bson:"time"`
+	LastHeartbeat time.Time `bson:"lastHeartbeat"`
+	Reason        string    `bson:"reason"`
+}
+
+func (restart RunRestart) ToDto() dtos.RunRestart {
+	return dtos.RunRestart{
+		Time:          restart.Time,
+		LastHeartbeat: restart.LastHeartbeat,
+		Reason:        restart.Reason,
+	}
+}
+
+func (restart *RunRestart) FromDto(dto dtos.RunRestart) {
+	restart.Time = dto.Time
+	restart.LastHeartbeat = dto.LastHeartbeat
+	restart.Reason = dto.Reason
+}
@@ -5,14 +5,114 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// The IDs of the latest messages applied to a run are kept to ignore
+// redeliveries of them.
+const MaxRunMessageIds = 100
+
 func RunUpdateFromDto(dto dtos.RunUpdate) bson.D {
 	setDoc := bson.D{}
 	setDoc = AppendBson(setDoc, "status", dto.Status)
 	setDoc = AppendBson(setDoc, "startTime", dto.StartTime)
 	setDoc = AppendBson(setDoc, "endTime", dto.EndTime)
+	setDoc = AppendBson(setDoc, "heartbeat", dto.Heartbeat)
+	setDoc = AppendBson(setDoc, "reason", dto.Reason)
+	setDoc = AppendBson(setDoc, "timedOut", dto.TimedOut)
+	setDoc = AppendBson(setDoc, "cancelledBy", dto.CancelledBy)
+	setDoc = AppendBson(setDoc, "cancelRequestedTime", dto.CancelRequestedTime)
+	setDoc = AppendBson(setDoc, "forced", dto.Forced)
+	setDoc = AppendBson(setDoc, "sequence", dto.Sequence)
+	setDoc = AppendBson(setDoc, "heartbeatSequence", dto.HeartbeatSequence)
+	setDoc = AppendBson(setDoc, "reported", dto.Reported)
+	setDoc = AppendBson(setDoc, "result", dto.Result)
+	setDoc = AppendBson(setDoc, "error", dto.Error)
+	setDoc = AppendBson(setDoc, "errorCode", dto.ErrorCode)
+	setDoc = AppendBson(setDoc, "exitCode", dto.ExitCode)
+	setDoc = AppendBson(setDoc, "progress", dto.Progress)
+	setDoc = AppendBson(setDoc, "progressMessage", dto.ProgressMessage)
+	setDoc = AppendBson(setDoc, "workerId", dto.WorkerId)
+	setDoc = AppendBson(setDoc, "routingKey", dto.RoutingKey)
+
+	if dto.Lease != nil {
+		lease := RunLease{}
+		lease.FromDto(*dto.Lease)
+		setDoc = append(setDoc, bson.E{
+			Key:   "lease",
+			Value: lease,
+		})
+	}
 
-	return bson.D{{
+	updateDoc := bson.D{{
 		Key:   "$set",
 		Value: setDoc,
 	}}
+
+	unsetDoc := bson.D{}
+	if dto.ClearLease {
+		unsetDoc = append(unsetDoc, bson.E{
+			Key:   "lease",
+			Value: "",
+		})
+	}
+
+	// A restarted run starts over, so the messages of the previous attempt no
+	// longer decide which messages are applied
+	if dto.ClearMessages {
+		unsetDoc = append(unsetDoc,
+			bson.E{Key: "sequence", Value: ""},
+			bson.E{Key: "heartbeatSequence", Value: ""},
+			bson.E{Key: "messageIds", Value: ""},
+		)
+	}
+
+	if dto.ClearWorker {
+		unsetDoc = append(unsetDoc, bson.E{
+			Key:   "workerId",
+			Value: "",
+		})
+	}
+
+	if len(unsetDoc) > 0 {
+		updateDoc = append(updateDoc, bson.E{
+			Key:   "$unset",
+			Value: unsetDoc,
+		})
+	}
+
+	pushDoc := bson.D{}
+	if dto.Restart != nil {
+		restart := RunRestart{}
+		restart.FromDto(*dto.Restart)
+		pushDoc = append(pushDoc, bson.E{
+			Key:   "restarts",
+			Value: restart,
+		})
+	}
+
+	if dto.Outbox != nil {
+		message := OutboxMessage{}
+		message.FromDto(*dto.Outbox)
+		pushDoc = append(pushDoc, bson.E{
+			Key:   "outbox",
+			Value: message,
+		})
+	}
+
+	if dto.MessageId != nil {
+		pushDoc = append(pushDoc, bson.E{
+			Key: "messageIds",
+			Value: bson.D{
+				{Key: "$each", Value: []string{*dto.MessageId}},
+				{Key: "$slice", Value: -MaxRunMessageIds},
+			},
+		})
+	}
+
+	if len(pushDoc) > 0 {
+		updateDoc = append(updateDoc, bson.E{
+			Key:   "$push",
+			Value: pushDoc,
+		})
+	}
+
+	return updateDoc
 }
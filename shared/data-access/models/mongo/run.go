@@ -9,24 +9,97 @@ import (
 )
 
 type Run struct {
-	Id          bson.ObjectID `bson:"_id,omitempty"`
-	JobName     string        `bson:"jobName"`
-	Status      string        `bson:"status"`
-	CreatedTime time.Time     `bson:"createdTime"`
-	StartTime   time.Time     `bson:"startTime"`
-	EndTime     time.Time     `bson:"endTime"`
-	Heartbeat   time.Time     `bson:"heartbeat"`
+	Id                  bson.ObjectID   `bson:"_id,omitempty"`
+	JobName             string          `bson:"jobName"`
+	Status              string          `bson:"status"`
+	CreatedTime         time.Time       `bson:"createdTime"`
+	Period              time.Time       `bson:"period"`
+	StartTime           time.Time       `bson:"startTime"`
+	EndTime             time.Time       `bson:"endTime"`
+	Heartbeat           time.Time       `bson:"heartbeat"`
+	Reason              string          `bson:"reason,omitempty"`
+	CancelledBy         string          `bson:"cancelledBy,omitempty"`
+	CancelRequestedTime time.Time       `bson:"cancelRequestedTime,omitempty"`
+	Forced              bool            `bson:"forced,omitempty"`
+	Attempt             int             `bson:"attempt"`
+	OriginalRunId       bson.ObjectID   `bson:"originalRunId,omitempty"`
+	TimedOut            bool            `bson:"timedOut,omitempty"`
+	Manual              bool            `bson:"manual,omitempty"`
+	Released            bool            `bson:"released,omitempty"`
+	Parameters          bson.M          `bson:"parameters,omitempty"`
+	Restarts            []RunRestart    `bson:"restarts,omitempty"`
+	Outbox              []OutboxMessage `bson:"outbox,omitempty"`
+	Sequence            int64           `bson:"sequence,omitempty"`
+	HeartbeatSequence   int64           `bson:"heartbeatSequence,omitempty"`
+	Reported            bool            `bson:"reported,omitempty"`
+	MessageIds          []string        `bson:"messageIds,omitempty"`
+	Result              bson.M          `bson:"result,omitempty"`
+	Error               string          `bson:"error,omitempty"`
+	ErrorCode           string          `bson:"errorCode,omitempty"`
+	ExitCode            *int            `bson:"exitCode,omitempty"`
+	Progress            float64         `bson:"progress,omitempty"`
+	ProgressMessage     string          `bson:"progressMessage,omitempty"`
+	Lease               *RunLease       `bson:"lease,omitempty"`
+	WorkerId            string          `bson:"workerId,omitempty"`
+	RoutingKey          string          `bson:"routingKey,omitempty"`
 }
 
 func (run Run) ToDto() dtos.Run {
+	originalRunId := ""
+	if !run.OriginalRunId.IsZero() {
+		originalRunId = run.OriginalRunId.Hex()
+	}
+
+	var restarts []dtos.RunRestart
+	for _, restart := range run.Restarts {
+		restarts = append(restarts, restart.ToDto())
+	}
+
+	var outbox []dtos.OutboxMessage
+	for _, message := range run.Outbox {
+		outbox = append(outbox, message.ToDto(run.JobName, run.Id.Hex()))
+	}
+
+	var lease *dtos.RunLease
+	if run.Lease != nil {
+		leaseDto := run.Lease.ToDto()
+		lease = &leaseDto
+	}
+
 	return dtos.Run{
-		Id:          run.Id.Hex(),
-		JobName:     run.JobName,
-		Status:      runStatuses.RunStatus(run.Status),
-		CreatedTime: run.CreatedTime,
-		StartTime:   run.StartTime,
-		EndTime:     run.EndTime,
-		Heartbeat:   run.Heartbeat,
+		Id:                  run.Id.Hex(),
+		JobName:             run.JobName,
+		Status:              runStatuses.RunStatus(run.Status),
+		CreatedTime:         run.CreatedTime,
+		Period:              run.Period,
+		StartTime:           run.StartTime,
+		EndTime:             run.EndTime,
+		Heartbeat:           run.Heartbeat,
+		Reason:              run.Reason,
+		CancelledBy:         run.CancelledBy,
+		CancelRequestedTime: run.CancelRequestedTime,
+		Forced:              run.Forced,
+		Attempt:             run.Attempt,
+		OriginalRunId:       originalRunId,
+		TimedOut:            run.TimedOut,
+		Manual:              run.Manual,
+		Released:            run.Released,
+		Parameters:          run.Parameters,
+		Restarts:            restarts,
+		Outbox:              outbox,
+		Sequence:            run.Sequence,
+		HeartbeatSequence:   run.HeartbeatSequence,
+		Reported:            run.Reported,
+		MessageIds:          run.MessageIds,
+		Result:              run.Result,
+		Error:               run.Error,
+		ErrorCode:           run.ErrorCode,
+		ExitCode:            run.ExitCode,
+		Progress:            run.Progress,
+		ProgressMessage:     run.ProgressMessage,
+		Lease:               lease,
+		WorkerId:            run.WorkerId,
+		RoutingKey:          run.RoutingKey,
 	}
 }
 
@@ -40,7 +113,55 @@ func (run *Run) FromDto(dto dtos.Run) {
 	run.JobName = dto.JobName
 	run.Status = string(dto.Status)
 	run.CreatedTime = dto.CreatedTime
+	run.Period = dto.Period
 	run.StartTime = dto.StartTime
 	run.EndTime = dto.EndTime
 	run.Heartbeat = dto.Heartbeat
+	run.Reason = dto.Reason
+	run.CancelledBy = dto.CancelledBy
+	run.CancelRequestedTime = dto.CancelRequestedTime
+	run.Forced = dto.Forced
+	run.Attempt = dto.Attempt
+	run.TimedOut = dto.TimedOut
+	run.Manual = dto.Manual
+	run.Released = dto.Released
+	run.Parameters = dto.Parameters
+	run.Sequence = dto.Sequence
+	run.HeartbeatSequence = dto.HeartbeatSequence
+	run.Reported = dto.Reported
+	run.MessageIds = dto.MessageIds
+	run.Result = dto.Result
+	run.Error = dto.Error
+	run.ErrorCode = dto.ErrorCode
+	run.ExitCode = dto.ExitCode
+	run.Progress = dto.Progress
+	run.ProgressMessage = dto.ProgressMessage
+	run.WorkerId = dto.WorkerId
+	run.RoutingKey = dto.RoutingKey
+
+	run.Restarts = nil
+	for _, restartDto := range dto.Restarts {
+		restart := RunRestart{}
+		restart.FromDto(restartDto)
+		run.Restarts = append(run.Restarts, restart)
+	}
+
+	run.Lease = nil
+	if dto.Lease != nil {
+		run.Lease = &RunLease{}
+		run.Lease.FromDto(*dto.Lease)
+	}
+
+	run.Outbox = nil
+	for _, messageDto := range dto.Outbox {
+		message := OutboxMessage{}
+		message.FromDto(messageDto)
+		run.Outbox = append(run.Outbox, message)
+	}
+
+	originalRunId, err := bson.ObjectIDFromHex(dto.OriginalRunId)
+	if err != nil {
+		originalRunId = bson.NilObjectID
+	}
+	run.OriginalRunId = originalRunId
 }
@@ -0,0 +1,14 @@
+package mongoModels
+
+import (
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func WorkerFilterFromDto(dto dtos.WorkerFilter) bson.D {
+	filter := bson.D{}
+	filter = AppendBsonCondition(filter, "jobNames", "$eq", dto.JobName)
+	filter = AppendBsonCondition(filter, "expiresTime", "$lt", dto.ExpiredBefore)
+
+	return filter
+}
@@ -0,0 +1,53 @@
+package mongoModels
+
+import (
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+)
+
+// Worker is keyed by the ID the worker chose for itself so that its
+// heartbeats can be applied without looking it up first.
+type Worker struct {
+	Id             string    `bson:"_id"`
+	Hostname       string    `bson:"hostname"`
+	JobNames       []string  `bson:"jobNames"`
+	Pool           string    `bson:"pool,omitempty"`
+	Tags           []string  `bson:"tags,omitempty"`
+	Capacity       int       `bson:"capacity"`
+	ActiveRuns     int       `bson:"activeRuns"`
+	Version        string    `bson:"version,omitempty"`
+	RegisteredTime time.Time `bson:"registeredTime"`
+	Heartbeat      time.Time `bson:"heartbeat"`
+	ExpiresTime    time.Time `bson:"expiresTime"`
+}
+
+func (worker Worker) ToDto() dtos.Worker {
+	return dtos.Worker{
+		Id:             worker.Id,
+		Hostname:       worker.Hostname,
+		JobNames:       worker.JobNames,
+		Pool:           worker.Pool,
+		Tags:           worker.Tags,
+		Capacity:       worker.Capacity,
+		ActiveRuns:     worker.ActiveRuns,
+		Version:        worker.Version,
+		RegisteredTime: worker.RegisteredTime,
+		Heartbeat:      worker.Heartbeat,
+		ExpiresTime:    worker.ExpiresTime,
+	}
+}
+
+func (worker *Worker) FromDto(dto dtos.Worker) {
+	worker.Id = dto.Id
+	worker.Hostname = dto.Hostname
+	worker.JobNames = dto.JobNames
+	worker.Pool = dto.Pool
+	worker.Tags = dto.Tags
+	worker.Capacity = dto.Capacity
+	worker.ActiveRuns = dto.ActiveRuns
+	worker.Version = dto.Version
+	worker.RegisteredTime = dto.RegisteredTime
+	worker.Heartbeat = dto.Heartbeat
+	worker.ExpiresTime = dto.ExpiresTime
+}
@@ -0,0 +1,9 @@
+package repositoryErrors
+
+type DuplicateError struct {
+	Message string
+}
+
+func (err *DuplicateError) Error() string {
+	return err.Message
+}
@@ -0,0 +1,13 @@
+package repositoryErrors
+
+import "fmt"
+
+type InvalidTransitionError struct {
+	Id   string
+	From string
+	To   string
+}
+
+func (err *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("Run %s cannot move from %s to %s", err.Id, err.From, err.To)
+}
@@ -0,0 +1,12 @@
+package repositoryErrors
+
+import "fmt"
+
+type LeaseLostError struct {
+	Id       string
+	WorkerId string
+}
+
+func (err *LeaseLostError) Error() string {
+	return fmt.Sprintf("Run %s is not leased by worker %s", err.Id, err.WorkerId)
+}
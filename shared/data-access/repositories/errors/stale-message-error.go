@@ -0,0 +1,17 @@
+package repositoryErrors
+
+import "fmt"
+
+type StaleMessageError struct {
+	Id        string
+	MessageId string
+	Sequence  int64
+}
+
+func (err *StaleMessageError) Error() string {
+	if err.MessageId != "" {
+		return fmt.Sprintf("Message %s for run %s was already applied or is older than the run", err.MessageId, err.Id)
+	}
+
+	return fmt.Sprintf("Message %d for run %s was already applied or is older than the run", err.Sequence, err.Id)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
@@ -29,6 +30,46 @@ func (dbContext *MongoDbContext) Connect(ctx context.Context) error {
 
 	dbContext.client = client
 	dbContext.db = dbContext.client.Database(dbContext.DbName)
+	if err := dbContext.createIndexes(); err != nil {
+		client.Disconnect(ctx)
+		dbContext.client = nil
+		return err
+	}
+
+	return nil
+}
+
+// createIndexes creates the indexes of the queries that run on every poll and
+// the indexes that keep runs unique. Indexes that already exist are left as
+// they are.
+func (dbContext *MongoDbContext) createIndexes() error {
+	runIndexes := []mongo.IndexModel{
+		// Runs with unsent actions are dispatched every request poll interval
+		{
+			Keys: bson.D{
+				{Key: "jobName", Value: 1},
+				{Key: "outbox.sent", Value: 1},
+				{Key: "outbox.dispatchTime", Value: 1},
+			},
+		},
+		// Upstream jobs that complete at the same time release only one run of
+		// each dependent job per period
+		{
+			Keys: bson.D{
+				{Key: "jobName", Value: 1},
+				{Key: "period", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "released", Value: true}}),
+		},
+	}
+
+	coll := dbContext.db.Collection(RunsCollection)
+	if _, err := coll.Indexes().CreateMany(dbContext.ctx, runIndexes); err != nil {
+		return fmt.Errorf("failed to create indexes of %s: %s", RunsCollection, err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,119 @@
+package mongoRepos
+
+import (
+	"fmt"
+
+	mongoModels "github.com/jacobmcgowan/simple-scheduler/shared/data-access/models/mongo"
+	repositoryErrors "github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories/errors"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const RunLogsCollection = "runLogs"
+
+type MongoRunLogRepository struct {
+	DbContext *MongoDbContext
+}
+
+// Browse returns the logs in the order they were added.
+func (repo MongoRunLogRepository) Browse(filter dtos.RunLogFilter) ([]dtos.RunLog, error) {
+	if err := validateRunLogFilter(filter); err != nil {
+		return nil, err
+	}
+
+	var logs []dtos.RunLog
+	filterDoc := mongoModels.RunLogFilterFromDto(filter)
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	coll := repo.DbContext.db.Collection(RunLogsCollection)
+	cur, err := coll.Find(repo.DbContext.ctx, filterDoc, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find run logs: %s", err)
+	}
+
+	for cur.Next(repo.DbContext.ctx) {
+		var runLog mongoModels.RunLog
+		err = cur.Decode(&runLog)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse run log: %s", err)
+		}
+
+		logs = append(logs, runLog.ToDto())
+	}
+
+	err = cur.Close(repo.DbContext.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close cursor: %s", err)
+	}
+
+	return logs, nil
+}
+
+func (repo MongoRunLogRepository) Count(filter dtos.RunLogFilter) (int64, error) {
+	if err := validateRunLogFilter(filter); err != nil {
+		return 0, err
+	}
+
+	filterDoc := mongoModels.RunLogFilterFromDto(filter)
+	coll := repo.DbContext.db.Collection(RunLogsCollection)
+	count, err := coll.CountDocuments(repo.DbContext.ctx, filterDoc)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count run logs: %s", err)
+	}
+
+	return count, nil
+}
+
+func (repo MongoRunLogRepository) Add(log dtos.RunLog) (string, error) {
+	if _, err := bson.ObjectIDFromHex(log.RunId); err != nil {
+		return "", &repositoryErrors.InvalidIdError{
+			Value: log.RunId,
+		}
+	}
+
+	logDoc := mongoModels.RunLog{}
+	logDoc.FromDto(log)
+
+	coll := repo.DbContext.db.Collection(RunLogsCollection)
+	res, err := coll.InsertOne(repo.DbContext.ctx, logDoc)
+	if err != nil {
+		return "", fmt.Errorf("failed to add run log: %s", err)
+	}
+
+	if id, ok := res.InsertedID.(bson.ObjectID); ok {
+		return id.Hex(), nil
+	}
+
+	return "", fmt.Errorf("failed to parse id of run log: %s", err)
+}
+
+func (repo MongoRunLogRepository) Delete(filter dtos.RunLogFilter) (int64, error) {
+	if err := validateRunLogFilter(filter); err != nil {
+		return 0, err
+	}
+
+	filterDoc := mongoModels.RunLogFilterFromDto(filter)
+	coll := repo.DbContext.db.Collection(RunLogsCollection)
+	res, err := coll.DeleteMany(repo.DbContext.ctx, filterDoc)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete run logs: %s", err)
+	}
+
+	return res.DeletedCount, nil
+}
+
+func validateRunLogFilter(filter dtos.RunLogFilter) error {
+	for _, id := range []*string{filter.RunId, filter.After} {
+		if id == nil {
+			continue
+		}
+
+		if _, err := bson.ObjectIDFromHex(*id); err != nil {
+			return &repositoryErrors.InvalidIdError{
+				Value: *id,
+			}
+		}
+	}
+
+	return nil
+}
@@ -2,12 +2,17 @@ package mongoRepos
 
 import (
 	"fmt"
+	"slices"
+	"time"
 
 	mongoModels "github.com/jacobmcgowan/simple-scheduler/shared/data-access/models/mongo"
 	repositoryErrors "github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories/errors"
 	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workerPools"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 const RunsCollection = "runs"
@@ -43,6 +48,17 @@ func (repo MongoRunRepository) Browse(filter dtos.RunFilter) ([]dtos.Run, error)
 	return runs, nil
 }
 
+func (repo MongoRunRepository) Count(filter dtos.RunFilter) (int64, error) {
+	filterDoc := mongoModels.RunFilterFromDto(filter)
+	coll := repo.DbContext.db.Collection(RunsCollection)
+	count, err := coll.CountDocuments(repo.DbContext.ctx, filterDoc)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count runs: %s", err)
+	}
+
+	return count, nil
+}
+
 func (repo MongoRunRepository) Read(id string) (dtos.Run, error) {
 	objId, err := bson.ObjectIDFromHex(id)
 	if err != nil {
@@ -90,8 +106,71 @@ func (repo MongoRunRepository) Edit(id string, update dtos.RunUpdate) error {
 			Value: objId,
 		}},
 	}}
+
+	// Status changes only apply if the run is in a status it can move from
+	if update.Status != nil {
+		filter = append(filter, bson.E{
+			Key: "status",
+			Value: bson.D{{
+				Key:   "$in",
+				Value: runStatuses.Sources(*update.Status),
+			}},
+		})
+	}
+
+	// Messages only apply once and only if they are newer than the last
+	// message of the same kind applied to the run, which also matches runs
+	// without a sequence. Status and heartbeat messages are consumed from
+	// separate queues, so they are only ordered among themselves.
+	if update.MessageId != nil {
+		filter = append(filter, bson.E{
+			Key: "messageIds",
+			Value: bson.D{{
+				Key:   "$ne",
+				Value: *update.MessageId,
+			}},
+		})
+	}
+
+	if update.Sequence != nil {
+		filter = append(filter, bson.E{
+			Key: "sequence",
+			Value: bson.D{{
+				Key: "$not",
+				Value: bson.D{{
+					Key:   "$gte",
+					Value: *update.Sequence,
+				}},
+			}},
+		})
+	}
+
+	if update.HeartbeatSequence != nil {
+		filter = append(filter, bson.E{
+			Key: "heartbeatSequence",
+			Value: bson.D{{
+				Key: "$not",
+				Value: bson.D{{
+					Key:   "$gte",
+					Value: *update.HeartbeatSequence,
+				}},
+			}},
+		})
+	}
+
+	// Updates from a lease holder only apply while it still holds the lease
+	if update.LeaseHolder != nil {
+		filter = append(filter, bson.E{
+			Key: "lease.workerId",
+			Value: bson.D{{
+				Key:   "$eq",
+				Value: *update.LeaseHolder,
+			}},
+		})
+	}
+
 	coll := repo.DbContext.db.Collection(RunsCollection)
-	_, err = coll.UpdateOne(repo.DbContext.ctx, filter, updateDoc)
+	res, err := coll.UpdateOne(repo.DbContext.ctx, filter, updateDoc)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return &repositoryErrors.NotFoundError{
@@ -102,6 +181,224 @@ func (repo MongoRunRepository) Edit(id string, update dtos.RunUpdate) error {
 		return fmt.Errorf("failed to edit run %s: %s", id, err)
 	}
 
+	conditional := update.Status != nil || update.MessageId != nil || update.Sequence != nil || update.HeartbeatSequence != nil || update.LeaseHolder != nil
+	if conditional && res.MatchedCount == 0 {
+		run, err := repo.Read(id)
+		if err != nil {
+			return err
+		}
+
+		if (update.MessageId != nil && slices.Contains(run.MessageIds, *update.MessageId)) ||
+			(update.Sequence != nil && run.Sequence >= *update.Sequence) ||
+			(update.HeartbeatSequence != nil && run.HeartbeatSequence >= *update.HeartbeatSequence) {
+			staleErr := &repositoryErrors.StaleMessageError{
+				Id: id,
+			}
+			if update.MessageId != nil {
+				staleErr.MessageId = *update.MessageId
+			}
+			if update.Sequence != nil {
+				staleErr.Sequence = *update.Sequence
+			}
+			if update.HeartbeatSequence != nil {
+				staleErr.Sequence = *update.HeartbeatSequence
+			}
+
+			return staleErr
+		}
+
+		if update.LeaseHolder != nil && (run.Lease == nil || run.Lease.WorkerId != *update.LeaseHolder) {
+			return &repositoryErrors.LeaseLostError{
+				Id:       id,
+				WorkerId: *update.LeaseHolder,
+			}
+		}
+
+		if update.Status != nil {
+			return &repositoryErrors.InvalidTransitionError{
+				Id:   id,
+				From: string(run.Status),
+				To:   string(*update.Status),
+			}
+		}
+	}
+
+	return nil
+}
+
+// Lease moves the oldest pending run of a job to running and assigns the lease
+// to it, so that the run is claimed by only one worker. Only runs routed with
+// one of the routing keys are leased, unless there are none.
+func (repo MongoRunRepository) Lease(jobName string, routingKeys []string, lease dtos.RunLease) (dtos.Run, error) {
+	runningStatus := runStatuses.Running
+	updateDoc := mongoModels.RunUpdateFromDto(dtos.RunUpdate{
+		Status:    &runningStatus,
+		StartTime: &lease.LeasedTime,
+		Heartbeat: &lease.LeasedTime,
+		Lease:     &lease,
+		WorkerId:  &lease.WorkerId,
+	})
+	filter := bson.D{
+		{
+			Key: "jobName",
+			Value: bson.D{{
+				Key:   "$eq",
+				Value: jobName,
+			}},
+		},
+		{
+			Key: "status",
+			Value: bson.D{{
+				Key:   "$eq",
+				Value: runStatuses.Pending,
+			}},
+		},
+	}
+	if len(routingKeys) > 0 {
+		keys := bson.A{}
+		for _, key := range routingKeys {
+			keys = append(keys, key)
+
+			// Runs routed with the action key were added before pools, or
+			// without one, and are stored without a routing key
+			if key == workerPools.RoutingKey("", nil) {
+				keys = append(keys, nil)
+			}
+		}
+
+		filter = append(filter, bson.E{
+			Key: "routingKey",
+			Value: bson.D{{
+				Key:   "$in",
+				Value: keys,
+			}},
+		})
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "createdTime", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var run mongoModels.Run
+	coll := repo.DbContext.db.Collection(RunsCollection)
+	err := coll.FindOneAndUpdate(repo.DbContext.ctx, filter, updateDoc, opts).Decode(&run)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return dtos.Run{}, &repositoryErrors.NotFoundError{
+				Message: fmt.Sprintf("failed to find pending run of job %s: %s", jobName, err),
+			}
+		}
+
+		return dtos.Run{}, fmt.Errorf("failed to lease run of job %s: %s", jobName, err)
+	}
+
+	return run.ToDto(), nil
+}
+
+func (repo MongoRunRepository) EditOutboxMessage(id string, messageId string, update dtos.OutboxMessageUpdate) error {
+	objId, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return &repositoryErrors.InvalidIdError{
+			Value: id,
+		}
+	}
+
+	messageObjId, err := bson.ObjectIDFromHex(messageId)
+	if err != nil {
+		return &repositoryErrors.InvalidIdError{
+			Value: messageId,
+		}
+	}
+
+	updateDoc := mongoModels.OutboxMessageUpdateFromDto(update)
+	filter := bson.D{
+		{
+			Key: "_id",
+			Value: bson.D{{
+				Key:   "$eq",
+				Value: objId,
+			}},
+		},
+		{
+			Key: "outbox.id",
+			Value: bson.D{{
+				Key:   "$eq",
+				Value: messageObjId,
+			}},
+		},
+	}
+	coll := repo.DbContext.db.Collection(RunsCollection)
+	res, err := coll.UpdateOne(repo.DbContext.ctx, filter, updateDoc)
+	if err != nil {
+		return fmt.Errorf("failed to edit outbox message %s of run %s: %s", messageId, id, err)
+	}
+
+	if res.MatchedCount == 0 {
+		return &repositoryErrors.NotFoundError{
+			Message: fmt.Sprintf("failed to find outbox message %s of run %s", messageId, id),
+		}
+	}
+
+	return nil
+}
+
+// ClaimOutboxMessage claims an unsent message in the outbox of a run until the
+// given time, so that only one dispatcher publishes it. Messages that are
+// claimed by another dispatcher, sent or discarded are not found.
+func (repo MongoRunRepository) ClaimOutboxMessage(id string, messageId string, claimedUntil time.Time) error {
+	objId, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return &repositoryErrors.InvalidIdError{
+			Value: id,
+		}
+	}
+
+	messageObjId, err := bson.ObjectIDFromHex(messageId)
+	if err != nil {
+		return &repositoryErrors.InvalidIdError{
+			Value: messageId,
+		}
+	}
+
+	// Messages without a claim also match as their claim is not set
+	filter := bson.D{
+		{
+			Key: "_id",
+			Value: bson.D{{
+				Key:   "$eq",
+				Value: objId,
+			}},
+		},
+		{
+			Key: "outbox",
+			Value: bson.D{{
+				Key: "$elemMatch",
+				Value: bson.D{
+					{Key: "id", Value: messageObjId},
+					{Key: "sent", Value: false},
+					{Key: "discarded", Value: bson.D{{Key: "$ne", Value: true}}},
+					{Key: "claimedUntil", Value: bson.D{{
+						Key:   "$not",
+						Value: bson.D{{Key: "$gte", Value: time.Now()}},
+					}}},
+				},
+			}},
+		},
+	}
+	updateDoc := mongoModels.OutboxMessageUpdateFromDto(dtos.OutboxMessageUpdate{
+		ClaimedUntil: &claimedUntil,
+	})
+	coll := repo.DbContext.db.Collection(RunsCollection)
+	res, err := coll.UpdateOne(repo.DbContext.ctx, filter, updateDoc)
+	if err != nil {
+		return fmt.Errorf("failed to claim outbox message %s of run %s: %s", messageId, id, err)
+	}
+
+	if res.MatchedCount == 0 {
+		return &repositoryErrors.NotFoundError{
+			Message: fmt.Sprintf("failed to find unclaimed outbox message %s of run %s", messageId, id),
+		}
+	}
+
 	return nil
 }
 
@@ -112,6 +409,12 @@ func (repo MongoRunRepository) Add(run dtos.Run) (string, error) {
 	coll := repo.DbContext.db.Collection(RunsCollection)
 	res, err := coll.InsertOne(repo.DbContext.ctx, runDoc)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return "", &repositoryErrors.DuplicateError{
+				Message: fmt.Sprintf("run of job %s for period %s was already released", run.JobName, run.Period),
+			}
+		}
+
 		return "", fmt.Errorf("failed to add run: %s", err)
 	}
 
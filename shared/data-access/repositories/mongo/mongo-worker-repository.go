@@ -0,0 +1,138 @@
+package mongoRepos
+
+import (
+	"fmt"
+
+	mongoModels "github.com/jacobmcgowan/simple-scheduler/shared/data-access/models/mongo"
+	repositoryErrors "github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories/errors"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const WorkersCollection = "workers"
+
+type MongoWorkerRepository struct {
+	DbContext *MongoDbContext
+}
+
+func (repo MongoWorkerRepository) Browse(filter dtos.WorkerFilter) ([]dtos.Worker, error) {
+	var workers []dtos.Worker
+	filterDoc := mongoModels.WorkerFilterFromDto(filter)
+	opts := options.Find().SetSort(bson.D{{Key: "hostname", Value: 1}, {Key: "_id", Value: 1}})
+	coll := repo.DbContext.db.Collection(WorkersCollection)
+	cur, err := coll.Find(repo.DbContext.ctx, filterDoc, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find workers: %s", err)
+	}
+
+	for cur.Next(repo.DbContext.ctx) {
+		var worker mongoModels.Worker
+		err = cur.Decode(&worker)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse worker: %s", err)
+		}
+
+		workers = append(workers, worker.ToDto())
+	}
+
+	err = cur.Close(repo.DbContext.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close cursor: %s", err)
+	}
+
+	return workers, nil
+}
+
+func (repo MongoWorkerRepository) Read(id string) (dtos.Worker, error) {
+	var worker mongoModels.Worker
+	filter := bson.D{{
+		Key: "_id",
+		Value: bson.D{{
+			Key:   "$eq",
+			Value: id,
+		}},
+	}}
+	coll := repo.DbContext.db.Collection(WorkersCollection)
+	err := coll.FindOne(repo.DbContext.ctx, filter).Decode(&worker)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return dtos.Worker{}, &repositoryErrors.NotFoundError{
+				Message: fmt.Sprintf("failed to find worker %s: %s", id, err),
+			}
+		}
+
+		return dtos.Worker{}, fmt.Errorf("failed to find worker %s: %s", id, err)
+	}
+
+	return worker.ToDto(), nil
+}
+
+// Register adds a worker or updates it if it is already registered. The time
+// the worker was first registered is kept.
+func (repo MongoWorkerRepository) Register(worker dtos.Worker) error {
+	workerDoc := mongoModels.Worker{}
+	workerDoc.FromDto(worker)
+
+	filter := bson.D{{
+		Key: "_id",
+		Value: bson.D{{
+			Key:   "$eq",
+			Value: workerDoc.Id,
+		}},
+	}}
+	updateDoc := bson.D{
+		{
+			Key: "$set",
+			Value: bson.D{
+				{Key: "hostname", Value: workerDoc.Hostname},
+				{Key: "jobNames", Value: workerDoc.JobNames},
+				{Key: "pool", Value: workerDoc.Pool},
+				{Key: "tags", Value: workerDoc.Tags},
+				{Key: "capacity", Value: workerDoc.Capacity},
+				{Key: "activeRuns", Value: workerDoc.ActiveRuns},
+				{Key: "version", Value: workerDoc.Version},
+				{Key: "heartbeat", Value: workerDoc.Heartbeat},
+				{Key: "expiresTime", Value: workerDoc.ExpiresTime},
+			},
+		},
+		{
+			Key: "$setOnInsert",
+			Value: bson.D{
+				{Key: "registeredTime", Value: workerDoc.RegisteredTime},
+			},
+		},
+	}
+	opts := options.UpdateOne().SetUpsert(true)
+
+	coll := repo.DbContext.db.Collection(WorkersCollection)
+	if _, err := coll.UpdateOne(repo.DbContext.ctx, filter, updateDoc, opts); err != nil {
+		return fmt.Errorf("failed to register worker %s: %s", worker.Id, err)
+	}
+
+	return nil
+}
+
+func (repo MongoWorkerRepository) Delete(id string) error {
+	filter := bson.D{{
+		Key: "_id",
+		Value: bson.D{{
+			Key:   "$eq",
+			Value: id,
+		}},
+	}}
+	coll := repo.DbContext.db.Collection(WorkersCollection)
+	res, err := coll.DeleteOne(repo.DbContext.ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete worker %s: %s", id, err)
+	}
+
+	if res.DeletedCount == 0 {
+		return &repositoryErrors.NotFoundError{
+			Message: fmt.Sprintf("failed to find worker %s", id),
+		}
+	}
+
+	return nil
+}
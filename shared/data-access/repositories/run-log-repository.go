@@ -0,0 +1,10 @@
+package repositories
+
+import "github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+
+type RunLogRepository interface {
+	Browse(filter dtos.RunLogFilter) ([]dtos.RunLog, error)
+	Count(filter dtos.RunLogFilter) (int64, error)
+	Add(log dtos.RunLog) (string, error)
+	Delete(filter dtos.RunLogFilter) (int64, error)
+}
@@ -1,11 +1,19 @@
 package repositories
 
-import "github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+import (
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+)
 
 type RunRepository interface {
 	Browse(filter dtos.RunFilter) ([]dtos.Run, error)
+	Count(filter dtos.RunFilter) (int64, error)
 	Read(id string) (dtos.Run, error)
 	Edit(id string, update dtos.RunUpdate) error
+	Lease(jobName string, routingKeys []string, lease dtos.RunLease) (dtos.Run, error)
+	EditOutboxMessage(id string, messageId string, update dtos.OutboxMessageUpdate) error
+	ClaimOutboxMessage(id string, messageId string, claimedUntil time.Time) error
 	Add(run dtos.Run) (string, error)
 	Delete(id string) error
 }
@@ -0,0 +1,10 @@
+package repositories
+
+import "github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+
+type WorkerRepository interface {
+	Browse(filter dtos.WorkerFilter) ([]dtos.Worker, error)
+	Read(id string) (dtos.Worker, error)
+	Register(worker dtos.Worker) error
+	Delete(id string) error
+}
@@ -0,0 +1,12 @@
+package dispatchModes
+
+// DispatchMode is how the runs of a job reach its workers.
+type DispatchMode string
+
+const (
+	// Push publishes the actions of runs to the message bus.
+	Push DispatchMode = "push"
+	// Lease leaves pending runs for workers to lease through the API, without
+	// publishing their actions.
+	Lease DispatchMode = "lease"
+)
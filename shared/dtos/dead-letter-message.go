@@ -0,0 +1,7 @@
+package dtos
+
+type DeadLetterMessage struct {
+	Queue string `json:"queue"`
+	Error string `json:"error"`
+	Body  string `json:"body"`
+}
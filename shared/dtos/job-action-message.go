@@ -1,7 +1,12 @@
 package dtos
 
 type JobActionMessage struct {
-	JobName string `json:"jobName"`
-	RunId   string `json:"runId"`
-	Action  string `json:"action"`
+	JobName    string         `json:"jobName"`
+	RunId      string         `json:"runId"`
+	Action     string         `json:"action"`
+	Attempt    int            `json:"attempt,omitempty"`
+	Parameters map[string]any `json:"parameters,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	Restart    int            `json:"restart,omitempty"`
+	WorkerId   string         `json:"workerId,omitempty"`
 }
@@ -1,6 +1,10 @@
 package dtos
 
 type JobHeartbeatMessage struct {
-	JobName string `json:"jobName"`
-	RunId   string `json:"runId"`
+	JobName         string   `json:"jobName"`
+	RunId           string   `json:"runId"`
+	MessageId       string   `json:"messageId,omitempty"`
+	Sequence        int64    `json:"sequence,omitempty"`
+	Progress        *float64 `json:"progress,omitempty"`
+	ProgressMessage string   `json:"progressMessage,omitempty"`
 }
@@ -0,0 +1,11 @@
+package dtos
+
+import "time"
+
+type JobLogMessage struct {
+	JobName string    `json:"jobName"`
+	RunId   string    `json:"runId"`
+	Time    time.Time `json:"time,omitempty"`
+	Level   string    `json:"level,omitempty"`
+	Message string    `json:"message"`
+}
@@ -1,7 +1,16 @@
 package dtos
 
 type JobStatusMessage struct {
-	JobName string `json:"jobName"`
-	RunId   string `json:"runId"`
-	Status  string `json:"status"`
+	JobName         string         `json:"jobName"`
+	RunId           string         `json:"runId"`
+	Status          string         `json:"status"`
+	MessageId       string         `json:"messageId,omitempty"`
+	Sequence        int64          `json:"sequence,omitempty"`
+	Result          map[string]any `json:"result,omitempty"`
+	Error           string         `json:"error,omitempty"`
+	ErrorCode       string         `json:"errorCode,omitempty"`
+	ExitCode        *int           `json:"exitCode,omitempty"`
+	Progress        *float64       `json:"progress,omitempty"`
+	ProgressMessage string         `json:"progressMessage,omitempty"`
+	WorkerId        string         `json:"workerId,omitempty"`
 }
@@ -5,12 +5,26 @@ import (
 )
 
 type JobUpdate struct {
-	Enabled             *bool      `json:"enabled,omitempty"`
-	NextRunAt           *time.Time `json:"nextRunAt,omitempty"`
-	Interval            *int       `json:"interval,omitempty"`
-	RunExecutionTimeout *int       `json:"runExecutionTimeout,omitempty"`
-	RunStartTimeout     *int       `json:"runStartTimeout,omitempty"`
-	MaxQueueCount       *int       `json:"maxQueueCount,omitempty"`
-	AllowConcurrentRuns *bool      `json:"allowConcurrentRuns,omitempty"`
-	HeartbeatTimeout    *int       `json:"heartbeatTimeout,omitempty"`
+	Enabled                *bool              `json:"enabled,omitempty"`
+	NextRunAt              *time.Time         `json:"nextRunAt,omitempty"`
+	Interval               *int               `json:"interval,omitempty"`
+	Schedule               *string            `json:"schedule,omitempty"`
+	DependsOn              *[]string          `json:"dependsOn,omitempty"`
+	TimeZone               *string            `json:"timeZone,omitempty"`
+	RunExecutionTimeout    *int               `json:"runExecutionTimeout,omitempty"`
+	RunStartTimeout        *int               `json:"runStartTimeout,omitempty"`
+	ExecutionTimeoutAction *string            `json:"executionTimeoutAction,omitempty"`
+	CancelGracePeriod      *int               `json:"cancelGracePeriod,omitempty"`
+	MaxQueueCount          *int               `json:"maxQueueCount,omitempty"`
+	AllowConcurrentRuns    *bool              `json:"allowConcurrentRuns,omitempty"`
+	ConcurrencyPolicy      *string            `json:"concurrencyPolicy,omitempty"`
+	MisfirePolicy          *string            `json:"misfirePolicy,omitempty"`
+	MisfireThreshold       *int               `json:"misfireThreshold,omitempty"`
+	RetryPolicy            *RetryPolicyUpdate `json:"retryPolicy,omitempty"`
+	Parameters             *map[string]any    `json:"parameters,omitempty"`
+	HeartbeatTimeout       *int               `json:"heartbeatTimeout,omitempty"`
+	MaxRestarts            *int               `json:"maxRestarts,omitempty"`
+	WorkerPool             *string            `json:"workerPool,omitempty"`
+	WorkerTags             *[]string          `json:"workerTags,omitempty"`
+	DispatchMode           *string            `json:"dispatchMode,omitempty"`
 }
@@ -6,30 +6,58 @@ import (
 )
 
 type Job struct {
-	Name                string    `json:"name" binding:"required"`
-	Enabled             bool      `json:"enabled" binding:"required"`
-	NextRunAt           time.Time `json:"nextRunAt" binding:"required"`
-	Interval            int       `json:"interval"`
-	RunExecutionTimeout int       `json:"runExecutionTimeout"`
-	RunStartTimeout     int       `json:"runStartTimeout"`
-	MaxQueueCount       int       `json:"maxQueueCount"`
-	AllowConcurrentRuns bool      `json:"allowConcurrentRuns"`
-	HeartbeatTimeout    int       `json:"heartbeatTimeout"`
-	ManagerId           string    `json:"managerId,omitempty"`
-	Heartbeat           time.Time `json:"heartbeat"`
+	Name                   string         `json:"name" binding:"required"`
+	Enabled                bool           `json:"enabled" binding:"required"`
+	NextRunAt              time.Time      `json:"nextRunAt" binding:"required_without_all=Schedule DependsOn"`
+	Interval               int            `json:"interval"`
+	Schedule               string         `json:"schedule,omitempty"`
+	DependsOn              []string       `json:"dependsOn,omitempty"`
+	TimeZone               string         `json:"timeZone,omitempty"`
+	RunExecutionTimeout    int            `json:"runExecutionTimeout"`
+	RunStartTimeout        int            `json:"runStartTimeout"`
+	ExecutionTimeoutAction string         `json:"executionTimeoutAction,omitempty"`
+	CancelGracePeriod      int            `json:"cancelGracePeriod"`
+	MaxQueueCount          int            `json:"maxQueueCount"`
+	AllowConcurrentRuns    bool           `json:"allowConcurrentRuns"`
+	ConcurrencyPolicy      string         `json:"concurrencyPolicy,omitempty"`
+	MisfirePolicy          string         `json:"misfirePolicy,omitempty"`
+	MisfireThreshold       int            `json:"misfireThreshold"`
+	RetryPolicy            RetryPolicy    `json:"retryPolicy"`
+	Parameters             map[string]any `json:"parameters,omitempty"`
+	HeartbeatTimeout       int            `json:"heartbeatTimeout"`
+	MaxRestarts            int            `json:"maxRestarts"`
+	WorkerPool             string         `json:"workerPool,omitempty"`
+	WorkerTags             []string       `json:"workerTags,omitempty"`
+	DispatchMode           string         `json:"dispatchMode,omitempty"`
+	ManagerId              string         `json:"managerId,omitempty"`
+	Heartbeat              time.Time      `json:"heartbeat"`
 }
 
 func (job *Job) UnmarshalJSON(data []byte) error {
 	var tmp struct {
-		Name                string    `json:"name" binding:"required"`
-		Enabled             bool      `json:"enabled" binding:"required"`
-		NextRunAt           time.Time `json:"nextRunAt" binding:"required"`
-		Interval            int       `json:"interval"`
-		RunExecutionTimeout int       `json:"runExecutionTimeout"`
-		RunStartTimeout     int       `json:"runStartTimeout"`
-		MaxQueueCount       int       `json:"maxQueueCount"`
-		AllowConcurrentRuns bool      `json:"allowConcurrentRuns"`
-		HeartbeatTimeout    int       `json:"heartbeatTimeout"`
+		Name                   string         `json:"name" binding:"required"`
+		Enabled                bool           `json:"enabled" binding:"required"`
+		NextRunAt              time.Time      `json:"nextRunAt" binding:"required_without_all=Schedule DependsOn"`
+		Interval               int            `json:"interval"`
+		Schedule               string         `json:"schedule,omitempty"`
+		DependsOn              []string       `json:"dependsOn,omitempty"`
+		TimeZone               string         `json:"timeZone,omitempty"`
+		RunExecutionTimeout    int            `json:"runExecutionTimeout"`
+		RunStartTimeout        int            `json:"runStartTimeout"`
+		ExecutionTimeoutAction string         `json:"executionTimeoutAction,omitempty"`
+		CancelGracePeriod      int            `json:"cancelGracePeriod"`
+		MaxQueueCount          int            `json:"maxQueueCount"`
+		AllowConcurrentRuns    bool           `json:"allowConcurrentRuns"`
+		ConcurrencyPolicy      string         `json:"concurrencyPolicy,omitempty"`
+		MisfirePolicy          string         `json:"misfirePolicy,omitempty"`
+		MisfireThreshold       int            `json:"misfireThreshold"`
+		RetryPolicy            RetryPolicy    `json:"retryPolicy"`
+		Parameters             map[string]any `json:"parameters,omitempty"`
+		HeartbeatTimeout       int            `json:"heartbeatTimeout"`
+		MaxRestarts            int            `json:"maxRestarts"`
+		WorkerPool             string         `json:"workerPool,omitempty"`
+		WorkerTags             []string       `json:"workerTags,omitempty"`
+		DispatchMode           string         `json:"dispatchMode,omitempty"`
 	}
 
 	if err := json.Unmarshal(data, &tmp); err != nil {
@@ -40,11 +68,25 @@ func (job *Job) UnmarshalJSON(data []byte) error {
 	job.Enabled = tmp.Enabled
 	job.NextRunAt = tmp.NextRunAt
 	job.Interval = tmp.Interval
+	job.Schedule = tmp.Schedule
+	job.DependsOn = tmp.DependsOn
+	job.TimeZone = tmp.TimeZone
 	job.RunExecutionTimeout = tmp.RunExecutionTimeout
 	job.RunStartTimeout = tmp.RunStartTimeout
+	job.ExecutionTimeoutAction = tmp.ExecutionTimeoutAction
+	job.CancelGracePeriod = tmp.CancelGracePeriod
 	job.MaxQueueCount = tmp.MaxQueueCount
 	job.AllowConcurrentRuns = tmp.AllowConcurrentRuns
+	job.ConcurrencyPolicy = tmp.ConcurrencyPolicy
+	job.MisfirePolicy = tmp.MisfirePolicy
+	job.MisfireThreshold = tmp.MisfireThreshold
+	job.RetryPolicy = tmp.RetryPolicy
+	job.Parameters = tmp.Parameters
 	job.HeartbeatTimeout = tmp.HeartbeatTimeout
+	job.MaxRestarts = tmp.MaxRestarts
+	job.WorkerPool = tmp.WorkerPool
+	job.WorkerTags = tmp.WorkerTags
+	job.DispatchMode = tmp.DispatchMode
 
 	return nil
 }
@@ -0,0 +1,13 @@
+package dtos
+
+import "time"
+
+type OutboxMessageUpdate struct {
+	Sent         *bool      `json:"sent,omitempty"`
+	SentTime     *time.Time `json:"sentTime,omitempty"`
+	DispatchTime *time.Time `json:"dispatchTime,omitempty"`
+	Attempts     *int       `json:"attempts,omitempty"`
+	Error        *string    `json:"error,omitempty"`
+	Discarded    *bool      `json:"discarded,omitempty"`
+	ClaimedUntil *time.Time `json:"claimedUntil,omitempty"`
+}
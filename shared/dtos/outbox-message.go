@@ -0,0 +1,16 @@
+package dtos
+
+import "time"
+
+type OutboxMessage struct {
+	Id           string           `json:"id"`
+	Action       JobActionMessage `json:"action"`
+	CreatedTime  time.Time        `json:"createdTime"`
+	DispatchTime time.Time        `json:"dispatchTime"`
+	Sent         bool             `json:"sent"`
+	SentTime     time.Time        `json:"sentTime"`
+	Attempts     int              `json:"attempts"`
+	Error        string           `json:"error,omitempty"`
+	Discarded    bool             `json:"discarded,omitempty"`
+	ClaimedUntil time.Time        `json:"claimedUntil"`
+}
@@ -0,0 +1,9 @@
+package dtos
+
+type RetryPolicyUpdate struct {
+	MaxAttempts    *int    `json:"maxAttempts,omitempty"`
+	Backoff        *string `json:"backoff,omitempty"`
+	Delay          *int    `json:"delay,omitempty"`
+	MaxDelay       *int    `json:"maxDelay,omitempty"`
+	RetryOnTimeout *bool   `json:"retryOnTimeout,omitempty"`
+}
@@ -0,0 +1,9 @@
+package dtos
+
+type RetryPolicy struct {
+	MaxAttempts    int    `json:"maxAttempts"`
+	Backoff        string `json:"backoff,omitempty"`
+	Delay          int    `json:"delay"`
+	MaxDelay       int    `json:"maxDelay"`
+	RetryOnTimeout bool   `json:"retryOnTimeout"`
+}
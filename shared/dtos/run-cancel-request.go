@@ -0,0 +1,5 @@
+package dtos
+
+type RunCancelRequest struct {
+	Reason string `json:"reason"`
+}
@@ -7,9 +7,15 @@ import (
 )
 
 type RunFilter struct {
-	JobName         *string                `json:"jobName,omitempty"`
-	Status          *runStatuses.RunStatus `json:"status,omitempty"`
-	CreatedBefore   *time.Time             `json:"createdBefore,omitempty"`
-	StartedBefore   *time.Time             `json:"startedBefore,omitempty"`
-	HeartbeatBefore *time.Time             `json:"heartbeatBefore,omitempty"`
+	JobName               *string                 `json:"jobName,omitempty"`
+	Status                *runStatuses.RunStatus  `json:"status,omitempty"`
+	Statuses              []runStatuses.RunStatus `json:"statuses,omitempty"`
+	Period                *time.Time              `json:"period,omitempty"`
+	CreatedBefore         *time.Time              `json:"createdBefore,omitempty"`
+	StartedBefore         *time.Time              `json:"startedBefore,omitempty"`
+	HeartbeatBefore       *time.Time              `json:"heartbeatBefore,omitempty"`
+	CancelRequestedBefore *time.Time              `json:"cancelRequestedBefore,omitempty"`
+	OutboxDueBefore       *time.Time              `json:"outboxDueBefore,omitempty"`
+	LeaseExpiredBefore    *time.Time              `json:"leaseExpiredBefore,omitempty"`
+	Reported              *bool                   `json:"reported,omitempty"`
 }
@@ -0,0 +1,8 @@
+package dtos
+
+type RunLeaseRenewal struct {
+	WorkerId        string   `json:"workerId"`
+	LeaseDuration   int      `json:"leaseDuration"`
+	Progress        *float64 `json:"progress,omitempty"`
+	ProgressMessage string   `json:"progressMessage,omitempty"`
+}
@@ -0,0 +1,9 @@
+package dtos
+
+type RunLeaseRequest struct {
+	WorkerId      string   `json:"workerId"`
+	LeaseDuration int      `json:"leaseDuration"`
+	Wait          int      `json:"wait,omitempty"`
+	Pool          string   `json:"pool,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
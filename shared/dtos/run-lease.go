@@ -0,0 +1,9 @@
+package dtos
+
+import "time"
+
+type RunLease struct {
+	WorkerId    string    `json:"workerId"`
+	LeasedTime  time.Time `json:"leasedTime"`
+	ExpiresTime time.Time `json:"expiresTime"`
+}
@@ -0,0 +1,10 @@
+package dtos
+
+import "time"
+
+type RunLogFilter struct {
+	JobName      *string    `json:"jobName,omitempty"`
+	RunId        *string    `json:"runId,omitempty"`
+	After        *string    `json:"after,omitempty"`
+	LoggedBefore *time.Time `json:"loggedBefore,omitempty"`
+}
@@ -0,0 +1,12 @@
+package dtos
+
+import "time"
+
+type RunLog struct {
+	Id      string    `json:"id"`
+	JobName string    `json:"jobName"`
+	RunId   string    `json:"runId"`
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level,omitempty"`
+	Message string    `json:"message"`
+}
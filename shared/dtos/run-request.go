@@ -0,0 +1,5 @@
+package dtos
+
+type RunRequest struct {
+	Parameters map[string]any `json:"parameters,omitempty"`
+}
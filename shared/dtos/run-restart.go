@@ -0,0 +1,9 @@
+package dtos
+
+import "time"
+
+type RunRestart struct {
+	Time          time.Time `json:"time"`
+	LastHeartbeat time.Time `json:"lastHeartbeat"`
+	Reason        string    `json:"reason"`
+}
@@ -0,0 +1,13 @@
+package dtos
+
+type RunStatusReport struct {
+	WorkerId        string         `json:"workerId"`
+	Status          string         `json:"status"`
+	MessageId       string         `json:"messageId,omitempty"`
+	Result          map[string]any `json:"result,omitempty"`
+	Error           string         `json:"error,omitempty"`
+	ErrorCode       string         `json:"errorCode,omitempty"`
+	ExitCode        *int           `json:"exitCode,omitempty"`
+	Progress        *float64       `json:"progress,omitempty"`
+	ProgressMessage string         `json:"progressMessage,omitempty"`
+}
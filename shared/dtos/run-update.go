@@ -7,8 +7,32 @@ import (
 )
 
 type RunUpdate struct {
-	Status    *runStatuses.RunStatus `json:"status,omitempty"`
-	StartTime *time.Time             `json:"startTime,omitempty"`
-	EndTime   *time.Time             `json:"endTime,omitempty"`
-	Heartbeat *time.Time             `json:"heartbeat,omitempty"`
+	Status              *runStatuses.RunStatus `json:"status,omitempty"`
+	StartTime           *time.Time             `json:"startTime,omitempty"`
+	EndTime             *time.Time             `json:"endTime,omitempty"`
+	Heartbeat           *time.Time             `json:"heartbeat,omitempty"`
+	Reason              *string                `json:"reason,omitempty"`
+	TimedOut            *bool                  `json:"timedOut,omitempty"`
+	CancelledBy         *string                `json:"cancelledBy,omitempty"`
+	CancelRequestedTime *time.Time             `json:"cancelRequestedTime,omitempty"`
+	Forced              *bool                  `json:"forced,omitempty"`
+	Restart             *RunRestart            `json:"restart,omitempty"`
+	Outbox              *OutboxMessage         `json:"outbox,omitempty"`
+	MessageId           *string                `json:"messageId,omitempty"`
+	Sequence            *int64                 `json:"sequence,omitempty"`
+	HeartbeatSequence   *int64                 `json:"heartbeatSequence,omitempty"`
+	Reported            *bool                  `json:"reported,omitempty"`
+	Result              *map[string]any        `json:"result,omitempty"`
+	Error               *string                `json:"error,omitempty"`
+	ErrorCode           *string                `json:"errorCode,omitempty"`
+	ExitCode            *int                   `json:"exitCode,omitempty"`
+	Progress            *float64               `json:"progress,omitempty"`
+	ProgressMessage     *string                `json:"progressMessage,omitempty"`
+	Lease               *RunLease              `json:"lease,omitempty"`
+	WorkerId            *string                `json:"workerId,omitempty"`
+	RoutingKey          *string                `json:"routingKey,omitempty"`
+	ClearLease          bool                   `json:"clearLease,omitempty"`
+	ClearMessages       bool                   `json:"clearMessages,omitempty"`
+	ClearWorker         bool                   `json:"clearWorker,omitempty"`
+	LeaseHolder         *string                `json:"leaseHolder,omitempty"`
 }
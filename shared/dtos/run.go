@@ -7,11 +7,37 @@ import (
 )
 
 type Run struct {
-	Id          string                `json:"id"`
-	JobName     string                `json:"jobName"`
-	Status      runStatuses.RunStatus `json:"status"`
-	CreatedTime time.Time             `json:"createdTime"`
-	StartTime   time.Time             `json:"startTime"`
-	EndTime     time.Time             `json:"endTime"`
-	Heartbeat   time.Time             `json:"heartbeat"`
+	Id                  string                `json:"id"`
+	JobName             string                `json:"jobName"`
+	Status              runStatuses.RunStatus `json:"status"`
+	CreatedTime         time.Time             `json:"createdTime"`
+	Period              time.Time             `json:"period"`
+	StartTime           time.Time             `json:"startTime"`
+	EndTime             time.Time             `json:"endTime"`
+	Heartbeat           time.Time             `json:"heartbeat"`
+	Reason              string                `json:"reason,omitempty"`
+	CancelledBy         string                `json:"cancelledBy,omitempty"`
+	CancelRequestedTime time.Time             `json:"cancelRequestedTime"`
+	Forced              bool                  `json:"forced,omitempty"`
+	Attempt             int                   `json:"attempt"`
+	OriginalRunId       string                `json:"originalRunId,omitempty"`
+	TimedOut            bool                  `json:"timedOut,omitempty"`
+	Manual              bool                  `json:"manual,omitempty"`
+	Released            bool                  `json:"released,omitempty"`
+	Parameters          map[string]any        `json:"parameters,omitempty"`
+	Restarts            []RunRestart          `json:"restarts,omitempty"`
+	Outbox              []OutboxMessage       `json:"outbox,omitempty"`
+	Sequence            int64                 `json:"sequence,omitempty"`
+	HeartbeatSequence   int64                 `json:"heartbeatSequence,omitempty"`
+	Reported            bool                  `json:"reported,omitempty"`
+	MessageIds          []string              `json:"messageIds,omitempty"`
+	Result              map[string]any        `json:"result,omitempty"`
+	Error               string                `json:"error,omitempty"`
+	ErrorCode           string                `json:"errorCode,omitempty"`
+	ExitCode            *int                  `json:"exitCode,omitempty"`
+	Progress            float64               `json:"progress,omitempty"`
+	ProgressMessage     string                `json:"progressMessage,omitempty"`
+	Lease               *RunLease             `json:"lease,omitempty"`
+	WorkerId            string                `json:"workerId,omitempty"`
+	RoutingKey          string                `json:"routingKey,omitempty"`
 }
@@ -0,0 +1,8 @@
+package dtos
+
+import "time"
+
+type WorkerFilter struct {
+	JobName       *string    `json:"jobName,omitempty"`
+	ExpiredBefore *time.Time `json:"expiredBefore,omitempty"`
+}
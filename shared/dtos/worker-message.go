@@ -0,0 +1,13 @@
+package dtos
+
+type WorkerMessage struct {
+	WorkerId          string   `json:"workerId"`
+	Hostname          string   `json:"hostname,omitempty"`
+	JobNames          []string `json:"jobNames,omitempty"`
+	Pool              string   `json:"pool,omitempty"`
+	Tags              []string `json:"tags,omitempty"`
+	Capacity          int      `json:"capacity,omitempty"`
+	ActiveRuns        int      `json:"activeRuns"`
+	Version           string   `json:"version,omitempty"`
+	HeartbeatInterval int      `json:"heartbeatInterval,omitempty"`
+}
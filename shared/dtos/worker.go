@@ -0,0 +1,17 @@
+package dtos
+
+import "time"
+
+type Worker struct {
+	Id             string    `json:"id"`
+	Hostname       string    `json:"hostname"`
+	JobNames       []string  `json:"jobNames"`
+	Pool           string    `json:"pool,omitempty"`
+	Tags           []string  `json:"tags,omitempty"`
+	Capacity       int       `json:"capacity"`
+	ActiveRuns     int       `json:"activeRuns"`
+	Version        string    `json:"version,omitempty"`
+	RegisteredTime time.Time `json:"registeredTime"`
+	Heartbeat      time.Time `json:"heartbeat"`
+	ExpiresTime    time.Time `json:"expiresTime"`
+}
@@ -0,0 +1,8 @@
+package dtos
+
+type WorkflowNode struct {
+	JobName   string   `json:"jobName"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+	Status    string   `json:"status"`
+	RunId     string   `json:"runId,omitempty"`
+}
@@ -0,0 +1,8 @@
+package dtos
+
+import "time"
+
+type Workflow struct {
+	Period time.Time      `json:"period"`
+	Nodes  []WorkflowNode `json:"nodes"`
+}
@@ -0,0 +1,20 @@
+package executorParameters
+
+// The run parameters the Executor reads what to run from.
+const (
+	Command    = "command"
+	Env        = "env"
+	WorkingDir = "workingDir"
+	Timeout    = "timeout"
+	Http       = "http"
+)
+
+// Reserved are the parameters that can only be set on the job, so that being
+// able to run a job does not allow changing what the Executor runs for it.
+var Reserved = []string{
+	Command,
+	Env,
+	WorkingDir,
+	Timeout,
+	Http,
+}
@@ -3,6 +3,7 @@ package jobActions
 type JobAction string
 
 const (
-	Run    JobAction = "run"
-	Cancel JobAction = "cancel"
+	Run     JobAction = "run"
+	Cancel  JobAction = "cancel"
+	Timeout JobAction = "timeout"
 )
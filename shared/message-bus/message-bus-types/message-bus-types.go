@@ -4,4 +4,5 @@ type MessageBusType string
 
 const (
 	RabbitMq MessageBusType = "rabbitmq"
+	Webhook  MessageBusType = "webhook"
 )
@@ -1,6 +1,17 @@
 package messageBus
 
-import "sync"
+import (
+	"errors"
+	"sync"
+)
+
+// WorkersExchange is the exchange workers send their heartbeat and unregister
+// messages to, which are not for a job.
+const WorkersExchange = "scheduler.workers"
+
+// ErrNoSubscribers is returned by Publish when a message bus that does not
+// store messages has nowhere to deliver one.
+var ErrNoSubscribers = errors.New("no subscribers")
 
 type MessageBus interface {
 	Connect() error
@@ -0,0 +1,65 @@
+package webhookMessageBus
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	KeyHeader       = "X-Simple-Scheduler-Key"
+	TimestampHeader = "X-Simple-Scheduler-Timestamp"
+	SignatureHeader = "X-Simple-Scheduler-Signature"
+	signaturePrefix = "sha256="
+)
+
+// SignatureTolerance is how far the timestamp of a signed request may be from
+// the current time, to prevent requests from being replayed later.
+const SignatureTolerance = time.Minute * 5
+
+// Sign returns the signature of a request body sent at timestamp to the escaped
+// URL path with a routing key, the hex encoded HMAC-SHA256 of
+// "<timestamp>.<path>.<key>.<body>" with the shared secret. Signing the path
+// and key prevents a signed message from being replayed to another job or as
+// another type of message.
+func Sign(secret string, timestamp int64, path string, key string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write([]byte(path))
+	mac.Write([]byte("."))
+	mac.Write([]byte(key))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns an error if the signature of a request body sent to the
+// escaped URL path with a routing key does not match or its timestamp is
+// outside of SignatureTolerance of now.
+func Verify(secret string, timestampStr string, signature string, path string, key string, body []byte, now time.Time) error {
+	timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+	if err != nil {
+		return errors.New("timestamp invalid")
+	}
+
+	sent := time.Unix(timestamp, 0)
+	if sent.Before(now.Add(-SignatureTolerance)) || sent.After(now.Add(SignatureTolerance)) {
+		return errors.New("timestamp outside of tolerance")
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return errors.New("signature invalid")
+	}
+
+	expected := Sign(secret, timestamp, path, key, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("signature invalid")
+	}
+
+	return nil
+}
@@ -0,0 +1,444 @@
+package webhookMessageBus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	messageBus "github.com/jacobmcgowan/simple-scheduler/shared/message-bus"
+)
+
+const (
+	exchangePrefix           = "scheduler.job."
+	jobNamePlaceholder       = "{jobName}"
+	maxCallbackBodyLength    = 1024 * 1024
+	defaultMaxAttempts       = 3
+	defaultRetryDelay        = time.Millisecond * 500
+	defaultPushTimeout       = time.Second * 5
+	defaultRequestTimeout    = time.Second * 10
+	unavailableRetryAfterSec = 5
+)
+
+// WebhookMessageBus delivers messages over HTTP instead of a broker. Messages
+// published with one of the PushKeys, by default only actions, are sent as
+// signed POST requests to the callback URL of the job. Clients send all other
+// messages, such as statuses and heartbeats, as signed POST requests to
+// /jobs/{jobName}/{key} on ListenAddress, which are delivered to the
+// subscribers of the queues bound to that key.
+type WebhookMessageBus struct {
+	ListenAddress string
+	Secret        string
+	// CallbackUrl is the URL actions are sent to, where {jobName} is replaced
+	// by the name of the job.
+	CallbackUrl string
+	// CallbackUrls are the URLs actions of specific jobs are sent to instead of
+	// CallbackUrl, by job name.
+	CallbackUrls map[string]string
+	PushKeys     []string
+	Client       *http.Client
+	MaxAttempts  int
+	RetryDelay   time.Duration
+	// PushTimeout is the longest a message may take to push, including
+	// retries, so that publishers are not blocked by an unavailable callback
+	// URL. Defaults to 5s.
+	PushTimeout time.Duration
+	lock        sync.RWMutex `default:"sync.RWMutex{}"`
+	connected   bool
+	bindings    map[string]map[string][]string
+	subscribers map[string]*subscriber
+	listener    net.Listener
+	server      *http.Server
+}
+
+type subscriber struct {
+	lock     sync.Mutex `default:"sync.Mutex{}"`
+	received func(body []byte) (error, bool)
+}
+
+func (msgBus *WebhookMessageBus) Connect() error {
+	msgBus.lock.Lock()
+	defer msgBus.lock.Unlock()
+
+	if msgBus.connected {
+		return nil
+	}
+
+	if msgBus.Secret == "" {
+		return errors.New("a secret is required to sign webhooks")
+	}
+
+	msgBus.bindings = map[string]map[string][]string{}
+	msgBus.subscribers = map[string]*subscriber{}
+	msgBus.connected = true
+	return nil
+}
+
+func (msgBus *WebhookMessageBus) Close() error {
+	msgBus.lock.Lock()
+	defer msgBus.lock.Unlock()
+
+	if !msgBus.connected {
+		return nil
+	}
+
+	msgBus.connected = false
+	clear(msgBus.subscribers)
+	if msgBus.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	defer cancel()
+
+	err := msgBus.server.Shutdown(ctx)
+	msgBus.server = nil
+	msgBus.listener = nil
+	if err != nil {
+		return fmt.Errorf("failed to stop listening for callbacks: %s", err)
+	}
+
+	return nil
+}
+
+func (msgBus *WebhookMessageBus) Register(exchange string, bindings map[string][]string) error {
+	msgBus.lock.Lock()
+	defer msgBus.lock.Unlock()
+
+	if !msgBus.connected {
+		return errors.New("a connection has not been established")
+	}
+
+	exchangeBindings, found := msgBus.bindings[exchange]
+	if !found {
+		exchangeBindings = map[string][]string{}
+		msgBus.bindings[exchange] = exchangeBindings
+	}
+
+	for queue, keys := range bindings {
+		for _, key := range keys {
+			if !slices.Contains(exchangeBindings[key], queue) {
+				exchangeBindings[key] = append(exchangeBindings[key], queue)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (msgBus *WebhookMessageBus) Publish(exchange string, key string, body []byte) error {
+	msgBus.lock.RLock()
+	connected := msgBus.connected
+	msgBus.lock.RUnlock()
+
+	if !connected {
+		return errors.New("a connection has not been established")
+	}
+
+	if msgBus.isPushKey(key) {
+		return msgBus.push(exchange, key, body)
+	}
+
+	// Messages are not stored, so one that cannot be delivered is reported to
+	// the publisher instead of being dropped
+	subscribers := msgBus.boundSubscribers(exchange, key)
+	if len(subscribers) == 0 {
+		return fmt.Errorf("%w for key %s of exchange %s", messageBus.ErrNoSubscribers, key, exchange)
+	}
+
+	errs := []error{}
+	for _, sub := range subscribers {
+		if err, _ := sub.receive(body); err != nil {
+			log.Printf("Failed to process message %s for key %s of exchange %s: %s", body, key, exchange, err)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (msgBus *WebhookMessageBus) Subscribe(wg *sync.WaitGroup, queue string, received func(body []byte) (error, bool)) error {
+	msgBus.lock.Lock()
+	defer msgBus.lock.Unlock()
+
+	if !msgBus.connected {
+		return errors.New("a connection has not been established")
+	}
+
+	if _, found := msgBus.subscribers[queue]; found {
+		return nil
+	}
+
+	// Listening starts with the first subscription so that services that only
+	// publish, such as the API, do not need a listen address
+	if msgBus.server == nil {
+		if err := msgBus.listen(); err != nil {
+			return fmt.Errorf("failed to subscribe: %s", err)
+		}
+	}
+
+	msgBus.subscribers[queue] = &subscriber{
+		received: received,
+	}
+	log.Printf("Subscribed to queue %s", queue)
+	return nil
+}
+
+func (msgBus *WebhookMessageBus) Unsubscribe(queue string) {
+	msgBus.lock.Lock()
+	defer msgBus.lock.Unlock()
+
+	if _, found := msgBus.subscribers[queue]; found {
+		delete(msgBus.subscribers, queue)
+		log.Printf("Unsubscribed from queue %s", queue)
+	}
+}
+
+// Addr returns the address callbacks are received on, or an empty string if
+// the bus is not listening.
+func (msgBus *WebhookMessageBus) Addr() string {
+	msgBus.lock.RLock()
+	defer msgBus.lock.RUnlock()
+
+	if msgBus.listener == nil {
+		return ""
+	}
+
+	return msgBus.listener.Addr().String()
+}
+
+func (msgBus *WebhookMessageBus) listen() error {
+	if msgBus.ListenAddress == "" {
+		return errors.New("a listen address is required to receive callbacks")
+	}
+
+	listener, err := net.Listen("tcp", msgBus.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %s", msgBus.ListenAddress, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs/{jobName}/{key}", msgBus.callbackReceived)
+	mux.HandleFunc("POST /workers/{key}", msgBus.callbackReceived)
+	msgBus.listener = listener
+	msgBus.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: defaultRequestTimeout,
+	}
+
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Stopped listening for callbacks: %s", err)
+		}
+	}(msgBus.server)
+
+	log.Printf("Listening for callbacks on %s", listener.Addr())
+	return nil
+}
+
+// callbackReceived delivers a message sent by a client to the subscribers of
+// the queues bound to its key. Clients should retry callbacks that fail with
+// 503 Service Unavailable, such as when the job is not managed by this
+// instance yet.
+func (msgBus *WebhookMessageBus) callbackReceived(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackBodyLength))
+	if err != nil {
+		http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	key := r.PathValue("key")
+	err = Verify(msgBus.Secret, r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), r.URL.EscapedPath(), key, body, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// Worker registrations are not for a job so they have their own exchange
+	exchange := messageBus.WorkersExchange
+	if jobName := r.PathValue("jobName"); jobName != "" {
+		exchange = exchangePrefix + jobName
+	}
+
+	subscribers := msgBus.boundSubscribers(exchange, key)
+	if len(subscribers) == 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(unavailableRetryAfterSec))
+		http.Error(w, fmt.Sprintf("no subscribers for %s of exchange %s", key, exchange), http.StatusServiceUnavailable)
+		return
+	}
+
+	// Every subscriber gets the message even if another fails to process it.
+	// The callback is retried if any of the failures can be retried.
+	errs := []error{}
+	requeueAny := false
+	for _, sub := range subscribers {
+		if err, requeue := sub.receive(body); err != nil {
+			log.Printf("Failed to process callback %s for key %s of exchange %s: %s", body, key, exchange, err)
+			errs = append(errs, err)
+			requeueAny = requeueAny || requeue
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		if requeueAny {
+			w.Header().Set("Retry-After", strconv.Itoa(unavailableRetryAfterSec))
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		} else {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		return
+	}
+
+	log.Printf("Processed callback %s for key %s of exchange %s", body, key, exchange)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// push sends a message to the callback URL of its job, retrying with an
+// increasing delay if the request fails or the response is a 408, 429 or 5xx.
+// Retries stop once PushTimeout has elapsed, leaving messages that still fail
+// to be published again by the caller, such as the outbox dispatcher.
+func (msgBus *WebhookMessageBus) push(exchange string, key string, body []byte) error {
+	callbackUrl, err := msgBus.callbackUrl(exchange)
+	if err != nil {
+		return err
+	}
+
+	maxAttempts := msgBus.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	delay := msgBus.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	pushTimeout := msgBus.PushTimeout
+	if pushTimeout <= 0 {
+		pushTimeout = defaultPushTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		retry, err := msgBus.send(ctx, callbackUrl, key, body)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= maxAttempts {
+			return fmt.Errorf("failed to send message %s to %s after %d attempts: %s", body, callbackUrl, attempt, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed to send message %s to %s after %d attempts: %s", body, callbackUrl, attempt, err)
+		case <-timer.C:
+		}
+
+		delay *= 2
+	}
+}
+
+func (msgBus *WebhookMessageBus) send(ctx context.Context, callbackUrl string, key string, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackUrl, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(KeyHeader, key)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(msgBus.Secret, timestamp, req.URL.EscapedPath(), key, body))
+
+	client := msgBus.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxCallbackBodyLength))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= 500
+	return retry, fmt.Errorf("callback returned %s", resp.Status)
+}
+
+func (msgBus *WebhookMessageBus) callbackUrl(exchange string) (string, error) {
+	jobName, isJob := strings.CutPrefix(exchange, exchangePrefix)
+	if !isJob {
+		return "", fmt.Errorf("exchange %s is not a job", exchange)
+	}
+
+	if callbackUrl, found := msgBus.CallbackUrls[jobName]; found {
+		return callbackUrl, nil
+	}
+
+	if msgBus.CallbackUrl == "" {
+		return "", fmt.Errorf("no callback URL for job %s", jobName)
+	}
+
+	return strings.ReplaceAll(msgBus.CallbackUrl, jobNamePlaceholder, url.PathEscape(jobName)), nil
+}
+
+// isPushKey returns whether messages published with a key are pushed to the
+// callback URL. Keys routed to a worker pool, such as action.gpu, are pushed
+// the same as the key they extend.
+func (msgBus *WebhookMessageBus) isPushKey(key string) bool {
+	pushKeys := msgBus.PushKeys
+	if len(pushKeys) == 0 {
+		pushKeys = []string{"action"}
+	}
+
+	return slices.ContainsFunc(pushKeys, func(pushKey string) bool {
+		return key == pushKey || strings.HasPrefix(key, pushKey+".")
+	})
+}
+
+func (msgBus *WebhookMessageBus) boundSubscribers(exchange string, key string) []*subscriber {
+	msgBus.lock.RLock()
+	defer msgBus.lock.RUnlock()
+
+	subscribers := []*subscriber{}
+	for _, queue := range msgBus.bindings[exchange][key] {
+		if sub, found := msgBus.subscribers[queue]; found {
+			subscribers = append(subscribers, sub)
+		}
+	}
+
+	return subscribers
+}
+
+// receive passes a message to the subscriber, one at a time like a queue.
+func (sub *subscriber) receive(body []byte) (error, bool) {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	return sub.received(body)
+}
@@ -0,0 +1,9 @@
+package misfirePolicies
+
+type MisfirePolicy string
+
+const (
+	FireAll  MisfirePolicy = "fireAll"
+	FireOnce MisfirePolicy = "fireOnce"
+	Skip     MisfirePolicy = "skip"
+)
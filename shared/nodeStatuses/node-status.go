@@ -0,0 +1,10 @@
+package nodeStatuses
+
+// NodeStatus is the status of a job in a workflow. Jobs with a run for the
+// workflow's period use the status of that run instead.
+type NodeStatus string
+
+const (
+	Waiting NodeStatus = "waiting"
+	Blocked NodeStatus = "blocked"
+)
@@ -0,0 +1,216 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories"
+	repositoryErrors "github.com/jacobmcgowan/simple-scheduler/shared/data-access/repositories/errors"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dispatchModes"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/jobActions"
+	messageBus "github.com/jacobmcgowan/simple-scheduler/shared/message-bus"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workerPools"
+)
+
+// Messages are claimed by a dispatcher before they are published, so that the
+// dispatchers of the API and Scheduler do not both publish them. The claim
+// expires in case the dispatcher stops before publishing.
+const claimDuration = time.Minute
+
+// Messages that fail to publish are retried with exponential backoff until
+// they have been attempted maxAttempts times.
+const (
+	retryDelay    = time.Second
+	maxRetryDelay = time.Minute * 5
+	maxAttempts   = 10
+)
+
+// Dispatcher publishes the action messages stored in the outbox of runs and
+// marks them as sent. Messages that fail to publish stay in the outbox and are
+// published again once their backoff delay has elapsed the next time the run
+// or its job is dispatched, so actions are delivered at least once. Actions are
+// published with the routing key of their run so that they reach the worker
+// pool the run was routed to. Messages that would no longer be acted on, such
+// as those of finished runs, are discarded instead.
+type Dispatcher struct {
+	MessageBus messageBus.MessageBus
+	RunRepo    repositories.RunRepository
+	lock       sync.Mutex `default:"sync.Mutex{}"`
+}
+
+// NewMessage returns an outbox message for an action that is due at
+// dispatchTime. Its ID is generated when it is stored.
+func NewMessage(action dtos.JobActionMessage, dispatchTime time.Time) *dtos.OutboxMessage {
+	return &dtos.OutboxMessage{
+		Action:       action,
+		CreatedTime:  time.Now(),
+		DispatchTime: dispatchTime,
+	}
+}
+
+// NewJobMessage returns an outbox message for an action of a run of the job, or
+// nil if the job's runs are leased through the API since their workers do not
+// consume actions.
+func NewJobMessage(job dtos.Job, action dtos.JobActionMessage, dispatchTime time.Time) *dtos.OutboxMessage {
+	if dispatchModes.DispatchMode(job.DispatchMode) == dispatchModes.Lease {
+		return nil
+	}
+
+	return NewMessage(action, dispatchTime)
+}
+
+// DispatchRun publishes the due messages in the outbox of a run.
+func (dispatcher *Dispatcher) DispatchRun(runId string) error {
+	dispatcher.lock.Lock()
+	defer dispatcher.lock.Unlock()
+
+	run, err := dispatcher.RunRepo.Read(runId)
+	if err != nil {
+		return fmt.Errorf("failed to read run %s: %s", runId, err)
+	}
+
+	return dispatcher.dispatch(run, time.Now())
+}
+
+// DispatchJob publishes the due messages in the outboxes of all runs of a job.
+func (dispatcher *Dispatcher) DispatchJob(jobName string) error {
+	dispatcher.lock.Lock()
+	defer dispatcher.lock.Unlock()
+
+	now := time.Now()
+	filter := dtos.RunFilter{
+		JobName:         &jobName,
+		OutboxDueBefore: &now,
+	}
+	runs, err := dispatcher.RunRepo.Browse(filter)
+	if err != nil {
+		return fmt.Errorf("failed to get runs with unsent actions for job %s: %s", jobName, err)
+	}
+
+	errs := []error{}
+	for _, run := range runs {
+		if err := dispatcher.dispatch(run, now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (dispatcher *Dispatcher) dispatch(run dtos.Run, now time.Time) error {
+	errs := []error{}
+	for _, message := range run.Outbox {
+		if message.Sent || message.Discarded || message.DispatchTime.After(now) {
+			continue
+		}
+
+		if reason := obsoleteReason(run, message); reason != "" {
+			discarded := true
+			update := dtos.OutboxMessageUpdate{
+				Discarded: &discarded,
+				Error:     &reason,
+			}
+			if err := dispatcher.RunRepo.EditOutboxMessage(run.Id, message.Id, update); err != nil {
+				errs = append(errs, fmt.Errorf("failed to discard %s action for run %s: %s", message.Action.Action, run.Id, err))
+			}
+
+			continue
+		}
+
+		if err := dispatcher.RunRepo.ClaimOutboxMessage(run.Id, message.Id, now.Add(claimDuration)); err != nil {
+			var notFoundErr *repositoryErrors.NotFoundError
+			if !errors.As(err, &notFoundErr) {
+				errs = append(errs, err)
+			}
+
+			continue
+		}
+
+		attempts := message.Attempts + 1
+		unclaimed := time.Time{}
+		update := dtos.OutboxMessageUpdate{
+			Attempts:     &attempts,
+			ClaimedUntil: &unclaimed,
+		}
+
+		// Workers take actions from a shared queue, so actions for a run that
+		// is being performed name its worker
+		action := message.Action
+		if jobActions.JobAction(action.Action) != jobActions.Run {
+			action.WorkerId = run.WorkerId
+		}
+
+		if err := dispatcher.publish(run.RoutingKey, action); err != nil {
+			errMsg := err.Error()
+			update.Error = &errMsg
+			if attempts >= maxAttempts {
+				discarded := true
+				update.Discarded = &discarded
+			} else {
+				dispatchTime := time.Now().Add(backoff(attempts))
+				update.DispatchTime = &dispatchTime
+			}
+
+			errs = append(errs, fmt.Errorf("failed to publish %s action for run %s after %d attempts: %s", message.Action.Action, run.Id, attempts, err))
+		} else {
+			sent := true
+			sentTime := time.Now()
+			update.Sent = &sent
+			update.SentTime = &sentTime
+		}
+
+		if err := dispatcher.RunRepo.EditOutboxMessage(run.Id, message.Id, update); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update outbox of run %s: %s", run.Id, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// obsoleteReason returns why a message would no longer be acted on if it were
+// published, or an empty string if it should be published. Runs that have
+// finished are not acted on, and run actions only apply to pending runs.
+func obsoleteReason(run dtos.Run, message dtos.OutboxMessage) string {
+	if runStatuses.IsFinished(run.Status) {
+		return fmt.Sprintf("run is %s", run.Status)
+	}
+
+	if jobActions.JobAction(message.Action.Action) == jobActions.Run && run.Status != runStatuses.Pending {
+		return fmt.Sprintf("run is %s", run.Status)
+	}
+
+	return ""
+}
+
+// backoff returns how long to wait before publishing a message again after it
+// has failed the given number of times.
+func backoff(attempts int) time.Duration {
+	delay := retryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxRetryDelay)
+}
+
+func (dispatcher *Dispatcher) publish(routingKey string, action dtos.JobActionMessage) error {
+	if routingKey == "" {
+		routingKey = workerPools.RoutingKey("", nil)
+	}
+
+	body, err := json.Marshal(action)
+	if err != nil {
+		return fmt.Errorf("failed to serialize action: %s", err)
+	}
+
+	return dispatcher.MessageBus.Publish(
+		"scheduler.job."+action.JobName,
+		routingKey,
+		body,
+	)
+}
@@ -24,6 +24,8 @@ type DbResources struct {
 	ManagerRepo repositories.ManagerRepository
 	JobRepo     repositories.JobRepository
 	RunRepo     repositories.RunRepository
+	RunLogRepo  repositories.RunLogRepository
+	WorkerRepo  repositories.WorkerRepository
 }
 
 func LoadDbEnv() DbEnv {
@@ -49,8 +51,11 @@ func RegisterRepos(env DbEnv) (DbResources, error) {
 	switch env.Type {
 	case string(dbTypes.MongoDb):
 		dbCtx := mongoRepos.MongoDbContext{
-			DbName:  env.Name,
-			Options: *options.Client().ApplyURI(env.ConnectionString),
+			DbName: env.Name,
+			Options: *options.Client().
+				ApplyURI(env.ConnectionString).
+				// Decode parameters as maps so they serialize to JSON objects
+				SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true}),
 		}
 		mngrRepo := mongoRepos.MongoManagerRepository{
 			DbContext: &dbCtx,
@@ -61,6 +66,12 @@ func RegisterRepos(env DbEnv) (DbResources, error) {
 		runRepo := mongoRepos.MongoRunRepository{
 			DbContext: &dbCtx,
 		}
+		runLogRepo := mongoRepos.MongoRunLogRepository{
+			DbContext: &dbCtx,
+		}
+		workerRepo := mongoRepos.MongoWorkerRepository{
+			DbContext: &dbCtx,
+		}
 
 		dbResources := DbResources{
 			Name:        env.Name + "@" + conStrUrl.Host,
@@ -68,6 +79,8 @@ func RegisterRepos(env DbEnv) (DbResources, error) {
 			ManagerRepo: mngrRepo,
 			JobRepo:     jobRepo,
 			RunRepo:     runRepo,
+			RunLogRepo:  runLogRepo,
+			WorkerRepo:  workerRepo,
 		}
 		return dbResources, nil
 	default:
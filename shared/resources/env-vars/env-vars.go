@@ -13,4 +13,20 @@ const (
 	HeartbeatInterval          = "SIMPLE_SCHEDULER_HEARTBEAT_INTERVAL"
 	ApiUrl                     = "SIMPLE_SCHEDULER_API_URL"
 	OidcIssuer                 = "SIMPLE_SCHEDULER_OIDC_ISSUER"
+	DefaultTimeZone            = "SIMPLE_SCHEDULER_DEFAULT_TIME_ZONE"
+	RequestPollInterval        = "SIMPLE_SCHEDULER_REQUEST_POLL_INTERVAL"
+	RunLogMaxLines             = "SIMPLE_SCHEDULER_RUN_LOG_MAX_LINES"
+	RunLogRetention            = "SIMPLE_SCHEDULER_RUN_LOG_RETENTION"
+	CancelGracePeriod          = "SIMPLE_SCHEDULER_CANCEL_GRACE_PERIOD"
+	ExecutorJobs               = "SIMPLE_SCHEDULER_EXECUTOR_JOBS"
+	ExecutorMaxConcurrentRuns  = "SIMPLE_SCHEDULER_EXECUTOR_MAX_CONCURRENT_RUNS"
+	ExecutorShutdownTimeout    = "SIMPLE_SCHEDULER_EXECUTOR_SHUTDOWN_TIMEOUT"
+	ExecutorShell              = "SIMPLE_SCHEDULER_EXECUTOR_SHELL"
+	ExecutorModes              = "SIMPLE_SCHEDULER_EXECUTOR_MODES"
+	ExecutorMaxResponseLength  = "SIMPLE_SCHEDULER_EXECUTOR_MAX_RESPONSE_LENGTH"
+	ExecutorPool               = "SIMPLE_SCHEDULER_EXECUTOR_POOL"
+	ExecutorTags               = "SIMPLE_SCHEDULER_EXECUTOR_TAGS"
+	WebhookSecret              = "SIMPLE_SCHEDULER_WEBHOOK_SECRET"
+	WebhookCallbackUrl         = "SIMPLE_SCHEDULER_WEBHOOK_CALLBACK_URL"
+	WebhookCallbackUrls        = "SIMPLE_SCHEDULER_WEBHOOK_CALLBACK_URLS"
 )
@@ -4,16 +4,21 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"strings"
 
 	messageBus "github.com/jacobmcgowan/simple-scheduler/shared/message-bus"
 	messageBusTypes "github.com/jacobmcgowan/simple-scheduler/shared/message-bus/message-bus-types"
 	"github.com/jacobmcgowan/simple-scheduler/shared/message-bus/rabbitmqMessageBus"
+	"github.com/jacobmcgowan/simple-scheduler/shared/message-bus/webhookMessageBus"
 	envVars "github.com/jacobmcgowan/simple-scheduler/shared/resources/env-vars"
 )
 
 type MessageBusEnv struct {
-	Type             string
-	ConnectionString string
+	Type                string
+	ConnectionString    string
+	WebhookSecret       string
+	WebhookCallbackUrl  string
+	WebhookCallbackUrls string
 }
 
 type MessageBusResources struct {
@@ -23,8 +28,11 @@ type MessageBusResources struct {
 
 func LoadMessageBusEnv() MessageBusEnv {
 	return MessageBusEnv{
-		Type:             os.Getenv(envVars.MessageBusType),
-		ConnectionString: os.Getenv(envVars.MessageBusConnectionString),
+		Type:                os.Getenv(envVars.MessageBusType),
+		ConnectionString:    os.Getenv(envVars.MessageBusConnectionString),
+		WebhookSecret:       os.Getenv(envVars.WebhookSecret),
+		WebhookCallbackUrl:  os.Getenv(envVars.WebhookCallbackUrl),
+		WebhookCallbackUrls: os.Getenv(envVars.WebhookCallbackUrls),
 	}
 }
 
@@ -48,6 +56,28 @@ func RegisterMessageBus(env MessageBusEnv) (MessageBusResources, error) {
 			MessageBus: &msgBus,
 		}
 		return msgBusResources, nil
+	case string(messageBusTypes.Webhook):
+		msgBusResources := MessageBusResources{
+			Name:       conStrUrl.Host + conStrUrl.Path,
+			MessageBus: nil,
+		}
+
+		callbackUrls, err := parseCallbackUrls(env.WebhookCallbackUrls)
+		if err != nil {
+			return msgBusResources, err
+		}
+
+		if env.WebhookCallbackUrl == "" && len(callbackUrls) == 0 {
+			return msgBusResources, fmt.Errorf("a webhook callback URL is required")
+		}
+
+		msgBusResources.MessageBus = &webhookMessageBus.WebhookMessageBus{
+			ListenAddress: conStrUrl.Host,
+			Secret:        env.WebhookSecret,
+			CallbackUrl:   env.WebhookCallbackUrl,
+			CallbackUrls:  callbackUrls,
+		}
+		return msgBusResources, nil
 	default:
 		msgBusResources := MessageBusResources{
 			Name:       conStrUrl.Host + conStrUrl.Path,
@@ -56,3 +86,22 @@ func RegisterMessageBus(env MessageBusEnv) (MessageBusResources, error) {
 		return msgBusResources, fmt.Errorf("message bus type %s not supported", env.Type)
 	}
 }
+
+// parseCallbackUrls parses a comma separated list of jobName=url pairs.
+func parseCallbackUrls(value string) (map[string]string, error) {
+	callbackUrls := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		jobName, callbackUrl, found := strings.Cut(pair, "=")
+		if !found || jobName == "" || callbackUrl == "" {
+			return nil, fmt.Errorf("webhook callback URL %s invalid, expected jobName=url", pair)
+		}
+
+		callbackUrls[jobName] = callbackUrl
+	}
+
+	return callbackUrls, nil
+}
@@ -3,10 +3,13 @@ package runStatuses
 type RunStatus string
 
 const (
+	Requested  RunStatus = "requested"
+	Queued     RunStatus = "queued"
 	Pending    RunStatus = "pending"
 	Running    RunStatus = "running"
 	Cancelling RunStatus = "cancelling"
 	Cancelled  RunStatus = "cancelled"
 	Failed     RunStatus = "failed"
 	Completed  RunStatus = "completed"
+	Skipped    RunStatus = "skipped"
 )
@@ -0,0 +1,47 @@
+package runStatuses
+
+import "slices"
+
+// transitions lists the statuses a run can move to from each status. Runs that
+// are cancelled, failed, completed or skipped have finished and cannot move.
+var transitions = map[RunStatus][]RunStatus{
+	Requested:  {Queued, Pending, Cancelled, Skipped},
+	Queued:     {Pending, Cancelled},
+	Pending:    {Running, Cancelling, Cancelled, Failed, Completed},
+	Running:    {Pending, Cancelling, Cancelled, Failed, Completed},
+	Cancelling: {Cancelled, Failed, Completed},
+}
+
+// CanTransition reports whether a run can move from one status to another.
+func CanTransition(from RunStatus, to RunStatus) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// Sources returns the statuses a run can move to the given status from.
+func Sources(to RunStatus) []RunStatus {
+	sources := []RunStatus{}
+	for _, from := range []RunStatus{Requested, Queued, Pending, Running, Cancelling} {
+		if CanTransition(from, to) {
+			sources = append(sources, from)
+		}
+	}
+
+	return sources
+}
+
+// IsFinished reports whether a run in the status has finished and cannot move
+// to another status.
+func IsFinished(status RunStatus) bool {
+	return len(transitions[status]) == 0
+}
+
+// IsClientStatus reports whether the status can be sent by a client in a
+// status message. The other statuses are only set by the Scheduler and API.
+func IsClientStatus(status RunStatus) bool {
+	switch status {
+	case Running, Cancelled, Failed, Completed:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,421 @@
+package schedules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Feb 29 on a given weekday can be decades apart.
+const maxSearchDays = 366 * 30
+
+type CronSchedule struct {
+	Expression  string
+	seconds     uint64
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	lastDom     bool
+	nthDows     []nthDow
+	domStar     bool
+	dowStar     bool
+}
+
+type nthDow struct {
+	weekday time.Weekday
+	nth     int
+}
+
+type fieldBounds struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+var (
+	secondBounds = fieldBounds{name: "second", min: 0, max: 59}
+	minuteBounds = fieldBounds{name: "minute", min: 0, max: 59}
+	hourBounds   = fieldBounds{name: "hour", min: 0, max: 23}
+	domBounds    = fieldBounds{name: "day of month", min: 1, max: 31}
+	monthBounds  = fieldBounds{
+		name: "month",
+		min:  1,
+		max:  12,
+		names: map[string]int{
+			"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+			"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+		},
+	}
+	dowBounds = fieldBounds{
+		name: "day of week",
+		min:  0,
+		max:  7,
+		names: map[string]int{
+			"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+		},
+	}
+)
+
+// ParseCron accepts 5 fields, 6 fields with leading seconds, or a macro such
+// as @daily. The day of month field also accepts L for the last day of the
+// month and the day of week field accepts D#N for the Nth weekday of the month.
+func ParseCron(expr string) (CronSchedule, error) {
+	sched := CronSchedule{
+		Expression: expr,
+	}
+
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@") {
+		macro, found := macros[strings.ToLower(spec)]
+		if !found {
+			return CronSchedule{}, fmt.Errorf("unsupported macro %s", spec)
+		}
+
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return CronSchedule{}, fmt.Errorf("expected 5 or 6 fields but found %d", len(fields))
+	}
+
+	var err error
+	if sched.seconds, err = parseField(fields[0], secondBounds); err != nil {
+		return CronSchedule{}, err
+	}
+	if sched.minutes, err = parseField(fields[1], minuteBounds); err != nil {
+		return CronSchedule{}, err
+	}
+	if sched.hours, err = parseField(fields[2], hourBounds); err != nil {
+		return CronSchedule{}, err
+	}
+	if sched.months, err = parseField(fields[4], monthBounds); err != nil {
+		return CronSchedule{}, err
+	}
+	if err = sched.parseDaysOfMonth(fields[3]); err != nil {
+		return CronSchedule{}, err
+	}
+	if err = sched.parseDaysOfWeek(fields[5]); err != nil {
+		return CronSchedule{}, err
+	}
+
+	return sched, nil
+}
+
+// Next evaluates the schedule against wall clock times in the location of the
+// given time and returns the zero time if no match is found. Wall clock times
+// skipped by a daylight saving transition are shifted forward by the length of
+// the transition, e.g. 02:30 becomes 03:30, and wall clock times repeated by a
+// transition only match their first occurrence.
+func (sched CronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	start := after.Truncate(time.Second).Add(time.Second)
+	year, month, day := start.Date()
+	hour, min, sec := start.Clock()
+
+	for i := 0; i < maxSearchDays; i++ {
+		date := time.Date(year, month, day+i, 0, 0, 0, 0, time.UTC)
+		if !sched.matchesDay(date) {
+			continue
+		}
+
+		fromHour, fromMin, fromSec := 0, 0, 0
+		if i == 0 {
+			fromHour, fromMin, fromSec = hour, min, sec
+		}
+
+		for {
+			h, m, s, found := sched.nextTimeOfDay(fromHour, fromMin, fromSec)
+			if !found {
+				break
+			}
+
+			next := resolveWallTime(date.Year(), date.Month(), date.Day(), h, m, s, loc)
+			if next.After(after) {
+				return next
+			}
+
+			fromHour, fromMin, fromSec = addSecond(h, m, s)
+		}
+	}
+
+	return time.Time{}
+}
+
+func (sched CronSchedule) matchesDay(date time.Time) bool {
+	if sched.months&(1<<uint(date.Month())) == 0 {
+		return false
+	}
+
+	domMatch := sched.matchesDayOfMonth(date)
+	dowMatch := sched.matchesDayOfWeek(date)
+
+	// Traditional cron matches either field when both are restricted
+	if sched.domStar || sched.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+func (sched CronSchedule) matchesDayOfMonth(date time.Time) bool {
+	if sched.daysOfMonth&(1<<uint(date.Day())) != 0 {
+		return true
+	}
+
+	return sched.lastDom && date.AddDate(0, 0, 1).Day() == 1
+}
+
+func (sched CronSchedule) matchesDayOfWeek(date time.Time) bool {
+	if sched.daysOfWeek&(1<<uint(date.Weekday())) != 0 {
+		return true
+	}
+
+	for _, nth := range sched.nthDows {
+		if date.Weekday() == nth.weekday && (date.Day()-1)/7+1 == nth.nth {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (sched CronSchedule) nextTimeOfDay(fromHour int, fromMin int, fromSec int) (int, int, int, bool) {
+	for h := fromHour; h <= hourBounds.max; h++ {
+		if sched.hours&(1<<uint(h)) == 0 {
+			continue
+		}
+
+		minStart := 0
+		if h == fromHour {
+			minStart = fromMin
+		}
+
+		for m := minStart; m <= minuteBounds.max; m++ {
+			if sched.minutes&(1<<uint(m)) == 0 {
+				continue
+			}
+
+			secStart := 0
+			if h == fromHour && m == fromMin {
+				secStart = fromSec
+			}
+
+			for s := secStart; s <= secondBounds.max; s++ {
+				if sched.seconds&(1<<uint(s)) != 0 {
+					return h, m, s, true
+				}
+			}
+		}
+	}
+
+	return 0, 0, 0, false
+}
+
+func addSecond(hour int, min int, sec int) (int, int, int) {
+	sec++
+	if sec > secondBounds.max {
+		sec = 0
+		min++
+	}
+	if min > minuteBounds.max {
+		min = 0
+		hour++
+	}
+
+	return hour, min, sec
+}
+
+func resolveWallTime(year int, month time.Month, day int, hour int, min int, sec int, loc *time.Location) time.Time {
+	wall := time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+
+	// The offsets a day either side cover both sides of any transition
+	_, offsetBefore := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, offsetAfter := wall.Add(24 * time.Hour).In(loc).Zone()
+
+	var first time.Time
+	for _, offset := range []int{offsetBefore, offsetAfter} {
+		instant := wall.Add(-time.Duration(offset) * time.Second)
+		local := instant.In(loc)
+		if local.Hour() != hour || local.Minute() != min || local.Second() != sec || local.Day() != day {
+			continue
+		}
+
+		if first.IsZero() || instant.Before(first) {
+			first = instant
+		}
+	}
+
+	if first.IsZero() {
+		return wall.Add(-time.Duration(offsetBefore) * time.Second).In(loc)
+	}
+
+	return first.In(loc)
+}
+
+func (sched *CronSchedule) parseDaysOfMonth(field string) error {
+	sched.domStar = strings.HasPrefix(field, "*") || field == "?"
+	if field == "?" {
+		field = "*"
+	}
+
+	parts := []string{}
+	for _, part := range strings.Split(field, ",") {
+		if strings.EqualFold(part, "L") {
+			sched.lastDom = true
+		} else {
+			parts = append(parts, part)
+		}
+	}
+
+	if len(parts) == 0 {
+		return nil
+	}
+
+	bits, err := parseField(strings.Join(parts, ","), domBounds)
+	if err != nil {
+		return err
+	}
+
+	sched.daysOfMonth = bits
+	return nil
+}
+
+func (sched *CronSchedule) parseDaysOfWeek(field string) error {
+	sched.dowStar = strings.HasPrefix(field, "*") || field == "?"
+	if field == "?" {
+		field = "*"
+	}
+
+	parts := []string{}
+	for _, part := range strings.Split(field, ",") {
+		day, nthStr, isNth := strings.Cut(part, "#")
+		if !isNth {
+			parts = append(parts, part)
+			continue
+		}
+
+		weekday, err := parseValue(day, dowBounds)
+		if err != nil {
+			return err
+		}
+
+		nth, err := strconv.Atoi(nthStr)
+		if err != nil || nth < 1 || nth > 5 {
+			return fmt.Errorf("invalid %s occurrence %s", dowBounds.name, part)
+		}
+
+		sched.nthDows = append(sched.nthDows, nthDow{
+			weekday: time.Weekday(weekday % 7),
+			nth:     nth,
+		})
+	}
+
+	if len(parts) == 0 {
+		return nil
+	}
+
+	bits, err := parseField(strings.Join(parts, ","), dowBounds)
+	if err != nil {
+		return err
+	}
+
+	// Sunday may be written as either 0 or 7.
+	if bits&(1<<7) != 0 {
+		bits |= 1
+	}
+
+	sched.daysOfWeek = bits
+	return nil
+}
+
+func parseField(field string, bounds fieldBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		partBits, err := parseRange(part, bounds)
+		if err != nil {
+			return 0, err
+		}
+
+		bits |= partBits
+	}
+
+	return bits, nil
+}
+
+func parseRange(part string, bounds fieldBounds) (uint64, error) {
+	rangeStr, stepStr, hasStep := strings.Cut(part, "/")
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepStr)
+		if err != nil || step < 1 {
+			return 0, fmt.Errorf("invalid %s step %s", bounds.name, part)
+		}
+	}
+
+	var start, end int
+	if rangeStr == "*" {
+		start, end = bounds.min, bounds.max
+	} else {
+		startStr, endStr, hasEnd := strings.Cut(rangeStr, "-")
+
+		var err error
+		if start, err = parseValue(startStr, bounds); err != nil {
+			return 0, err
+		}
+
+		switch {
+		case hasEnd:
+			if end, err = parseValue(endStr, bounds); err != nil {
+				return 0, err
+			}
+		case hasStep:
+			end = bounds.max
+		default:
+			end = start
+		}
+	}
+
+	if start > end {
+		return 0, fmt.Errorf("invalid %s range %s", bounds.name, part)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << uint(i)
+	}
+
+	return bits, nil
+}
+
+func parseValue(value string, bounds fieldBounds) (int, error) {
+	if num, found := bounds.names[strings.ToLower(value)]; found {
+		return num, nil
+	}
+
+	num, err := strconv.Atoi(value)
+	if err != nil || num < bounds.min || num > bounds.max {
+		return 0, fmt.Errorf("invalid %s %s", bounds.name, value)
+	}
+
+	return num, nil
+}
@@ -0,0 +1,42 @@
+package schedules
+
+import (
+	"fmt"
+	"time"
+)
+
+func LoadLocation(timeZone string, defaultLoc *time.Location) (*time.Location, error) {
+	if timeZone == "" {
+		if defaultLoc == nil {
+			return time.UTC, nil
+		}
+
+		return defaultLoc, nil
+	}
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %s: %s", timeZone, err)
+	}
+
+	return loc, nil
+}
+
+func NextCronTime(expr string, timeZone string, defaultLoc *time.Location, after time.Time) (time.Time, error) {
+	sched, err := ParseCron(expr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule %s: %s", expr, err)
+	}
+
+	loc, err := LoadLocation(timeZone, defaultLoc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := sched.Next(after.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("schedule %s has no next run time", expr)
+	}
+
+	return next.UTC(), nil
+}
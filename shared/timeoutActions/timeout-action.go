@@ -0,0 +1,9 @@
+package timeoutActions
+
+type TimeoutAction string
+
+const (
+	Cancel TimeoutAction = "cancel"
+	Fail   TimeoutAction = "fail"
+	Notify TimeoutAction = "notify"
+)
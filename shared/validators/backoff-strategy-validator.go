@@ -0,0 +1,15 @@
+package validators
+
+import "github.com/jacobmcgowan/simple-scheduler/shared/backoffStrategies"
+
+func ValidateBackoffStrategy(val string, allowNone bool) bool {
+	switch val {
+	case string(backoffStrategies.Fixed),
+		string(backoffStrategies.Exponential):
+		return true
+	case "":
+		return allowNone
+	default:
+		return false
+	}
+}
@@ -0,0 +1,15 @@
+package validators
+
+import "github.com/jacobmcgowan/simple-scheduler/shared/concurrencyPolicies"
+
+func ValidateConcurrencyPolicy(val string, allowNone bool) bool {
+	switch val {
+	case string(concurrencyPolicies.Skip),
+		string(concurrencyPolicies.Queue):
+		return true
+	case "":
+		return allowNone
+	default:
+		return false
+	}
+}
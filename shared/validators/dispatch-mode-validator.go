@@ -0,0 +1,15 @@
+package validators
+
+import "github.com/jacobmcgowan/simple-scheduler/shared/dispatchModes"
+
+func ValidateDispatchMode(val string, allowNone bool) bool {
+	switch val {
+	case string(dispatchModes.Push),
+		string(dispatchModes.Lease):
+		return true
+	case "":
+		return allowNone
+	default:
+		return false
+	}
+}
@@ -0,0 +1,16 @@
+package validators
+
+import "github.com/jacobmcgowan/simple-scheduler/shared/misfirePolicies"
+
+func ValidateMisfirePolicy(val string, allowNone bool) bool {
+	switch val {
+	case string(misfirePolicies.FireAll),
+		string(misfirePolicies.FireOnce),
+		string(misfirePolicies.Skip):
+		return true
+	case "":
+		return allowNone
+	default:
+		return false
+	}
+}
@@ -0,0 +1,7 @@
+package validators
+
+// ValidateProgress returns whether a reported progress, if any, is a
+// percentage.
+func ValidateProgress(val *float64) bool {
+	return val == nil || (*val >= 0 && *val <= 100)
+}
@@ -0,0 +1,19 @@
+package validators
+
+import (
+	"slices"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/executorParameters"
+)
+
+// ValidateRunParameters returns whether the parameters given for a manual run
+// leave the reserved parameters of the job untouched.
+func ValidateRunParameters(parameters map[string]any) bool {
+	for name := range parameters {
+		if slices.Contains(executorParameters.Reserved, name) {
+			return false
+		}
+	}
+
+	return true
+}
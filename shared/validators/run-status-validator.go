@@ -9,7 +9,10 @@ func ValidateRunStatus(val string, allowNone bool) bool {
 		string(runStatuses.Completed),
 		string(runStatuses.Failed),
 		string(runStatuses.Pending),
-		string(runStatuses.Running):
+		string(runStatuses.Queued),
+		string(runStatuses.Requested),
+		string(runStatuses.Running),
+		string(runStatuses.Skipped):
 		return true
 	case "":
 		return allowNone
@@ -0,0 +1,12 @@
+package validators
+
+import "github.com/jacobmcgowan/simple-scheduler/shared/schedules"
+
+func ValidateSchedule(val string, allowNone bool) bool {
+	if val == "" {
+		return allowNone
+	}
+
+	_, err := schedules.ParseCron(val)
+	return err == nil
+}
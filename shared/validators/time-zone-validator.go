@@ -0,0 +1,12 @@
+package validators
+
+import "time"
+
+func ValidateTimeZone(val string, allowNone bool) bool {
+	if val == "" {
+		return allowNone
+	}
+
+	_, err := time.LoadLocation(val)
+	return err == nil
+}
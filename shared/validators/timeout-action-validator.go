@@ -0,0 +1,16 @@
+package validators
+
+import "github.com/jacobmcgowan/simple-scheduler/shared/timeoutActions"
+
+func ValidateTimeoutAction(val string, allowNone bool) bool {
+	switch val {
+	case string(timeoutActions.Cancel),
+		string(timeoutActions.Fail),
+		string(timeoutActions.Notify):
+		return true
+	case "":
+		return allowNone
+	default:
+		return false
+	}
+}
@@ -0,0 +1,27 @@
+package validators
+
+import "github.com/jacobmcgowan/simple-scheduler/shared/workerPools"
+
+func ValidateWorkerPool(val string, allowNone bool) bool {
+	if val == "" {
+		return allowNone
+	}
+
+	return workerPools.ValidName(val)
+}
+
+// ValidateWorkerTags returns whether the tags of a job or worker can be used
+// in routing keys.
+func ValidateWorkerTags(tags []string) bool {
+	if len(tags) > workerPools.MaxTags {
+		return false
+	}
+
+	for _, tag := range tags {
+		if !workerPools.ValidName(tag) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,17 @@
+package worker
+
+import "context"
+
+// Handler performs the runs of a job. The context is cancelled when a cancel
+// action is received for the run or the worker is shutting down, and
+// context.Cause returns ErrCancelled or ErrShutdown respectively.
+type Handler interface {
+	Run(ctx context.Context, run *Run) error
+}
+
+// HandlerFunc allows an ordinary function to be used as a Handler.
+type HandlerFunc func(ctx context.Context, run *Run) error
+
+func (fn HandlerFunc) Run(ctx context.Context, run *Run) error {
+	return fn(ctx, run)
+}
@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrCancelled is the cause of a run's context being cancelled because a
+	// cancel action was received for it.
+	ErrCancelled = errors.New("run cancelled")
+	// ErrShutdown is the cause of a run's context being cancelled because the
+	// worker stopped before the run finished.
+	ErrShutdown = errors.New("worker shut down")
+)
+
+// RunError is an error returned by a Handler to report an error code or exit
+// code along with the error of a failed run.
+type RunError struct {
+	Err      error
+	Code     string
+	ExitCode *int
+}
+
+func (err *RunError) Error() string {
+	if err.Code == "" {
+		return err.Err.Error()
+	}
+
+	return fmt.Sprintf("%s: %s", err.Code, err.Err)
+}
+
+func (err *RunError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+)
+
+// Run is a run of a job being performed by a worker.
+type Run struct {
+	Id              string
+	JobName         string
+	Attempt         int
+	Restart         int
+	Parameters      map[string]any
+	worker          *Worker
+	lock            sync.Mutex `default:"sync.Mutex{}"`
+	lastSequence    int64
+	progress        *float64
+	progressMessage string
+	result          map[string]any
+	exitCode        *int
+	cancel          func(cause error)
+}
+
+// SetProgress records the percentage of the run that is complete, between 0
+// and 100, and what the run is currently doing. It is sent with the next
+// heartbeat.
+func (run *Run) SetProgress(progress float64, message string) error {
+	if progress < 0 || progress > 100 {
+		return fmt.Errorf("progress %v must be between 0 and 100", progress)
+	}
+
+	run.lock.Lock()
+	defer run.lock.Unlock()
+	run.progress = &progress
+	run.progressMessage = message
+	return nil
+}
+
+// SetResult records the output of the run. It is sent with the run's final
+// status.
+func (run *Run) SetResult(result map[string]any) {
+	run.lock.Lock()
+	defer run.lock.Unlock()
+	run.result = maps.Clone(result)
+}
+
+// SetExitCode records the exit code of the process that performed the run. It
+// is sent with the run's final status.
+func (run *Run) SetExitCode(exitCode int) {
+	run.lock.Lock()
+	defer run.lock.Unlock()
+	run.exitCode = &exitCode
+}
+
+// Log publishes a line to the run's log.
+func (run *Run) Log(level string, message string) error {
+	body, err := json.Marshal(dtos.JobLogMessage{
+		JobName: run.JobName,
+		RunId:   run.Id,
+		Time:    time.Now(),
+		Level:   level,
+		Message: message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize log for run %s: %s", run.Id, err)
+	}
+
+	if err := run.worker.publish("log", body); err != nil {
+		return fmt.Errorf("failed to publish log for run %s: %s", run.Id, err)
+	}
+
+	return nil
+}
+
+// Logf publishes a formatted line to the run's log.
+func (run *Run) Logf(level string, format string, args ...any) error {
+	return run.Log(level, fmt.Sprintf(format, args...))
+}
+
+// nextSequence returns a sequence for the next status or heartbeat message of
+// the run. Sequences are based on the current time so that they keep
+// increasing when the run is restarted by another worker. The Scheduler orders
+// status and heartbeat messages separately, so sharing the sequence between
+// them only leaves gaps.
+func (run *Run) nextSequence() int64 {
+	run.lock.Lock()
+	defer run.lock.Unlock()
+
+	sequence := time.Now().UnixNano()
+	if sequence <= run.lastSequence {
+		sequence = run.lastSequence + 1
+	}
+
+	run.lastSequence = sequence
+	return sequence
+}
+
+func (run *Run) currentProgress() (*float64, string) {
+	run.lock.Lock()
+	defer run.lock.Unlock()
+	return run.progress, run.progressMessage
+}
+
+func (run *Run) currentResult() (map[string]any, *int) {
+	run.lock.Lock()
+	defer run.lock.Unlock()
+	return run.result, run.exitCode
+}
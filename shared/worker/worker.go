@@ -0,0 +1,559 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/jobActions"
+	messageBus "github.com/jacobmcgowan/simple-scheduler/shared/message-bus"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/validators"
+	"github.com/jacobmcgowan/simple-scheduler/shared/workerPools"
+)
+
+const (
+	defaultHeartbeatDuration = time.Second * 10
+	defaultMaxConcurrentRuns = 1
+	defaultShutdownTimeout   = time.Second * 30
+)
+
+// Actions for runs performed by another worker are returned to the queue at
+// most maxActionRequeues times by each worker, in case that worker stopped.
+// Requeue counts are forgotten once maxRequeuedActions actions are tracked.
+const (
+	maxActionRequeues  = 10
+	maxRequeuedActions = 1000
+)
+
+// Worker consumes the actions of a job and performs its runs with a Handler.
+// It reports the status of each run, sends heartbeats while the run is being
+// performed and cancels the run's context when a cancel action is received.
+// While started, it also registers itself with the Scheduler's worker
+// registry.
+type Worker struct {
+	JobName    string
+	MessageBus messageBus.MessageBus
+	Handler    Handler
+	// Pool is the worker pool the worker performs runs for. Only runs of jobs
+	// in the same pool are received. Defaults to the default pool.
+	Pool string
+	// Tags are the capabilities of the worker. Only runs of jobs that require
+	// a subset of them are received.
+	Tags []string
+	// Id identifies the worker in the registry and the runs it performs.
+	// Defaults to a random ID when the worker is started.
+	Id string
+	// Hostname is reported to the registry. Defaults to the host's name.
+	Hostname string
+	// Version is reported to the registry, such as the version of the
+	// application performing the runs.
+	Version string
+	// HeartbeatDuration is how often heartbeats are sent for each run and to
+	// the registry. It should be shorter than the job's heartbeatTimeout.
+	// Defaults to 10s.
+	HeartbeatDuration time.Duration
+	// MaxConcurrentRuns is the number of runs performed at the same time. Run
+	// actions received while this many runs are being performed are requeued
+	// so that another worker can take them. Defaults to 1.
+	MaxConcurrentRuns int
+	// ShutdownTimeout is how long Stop waits for runs to finish before
+	// cancelling them. Defaults to 30s.
+	ShutdownTimeout time.Duration
+	// RunTimedOut is called when a timeout action is received for a run that
+	// is being performed. The run keeps going unless the callback stops it.
+	RunTimedOut     func(run *Run)
+	isRunningLock   sync.Mutex `default:"sync.Mutex{}"`
+	isRunning       bool
+	actionQueues    []string
+	runsLock        sync.Mutex `default:"sync.Mutex{}"`
+	runs            map[string]*Run
+	requeuedActions map[string]int
+	stopping        bool
+	runsWg          sync.WaitGroup
+	quit            chan struct{}
+}
+
+func (worker *Worker) Start(wg *sync.WaitGroup) error {
+	worker.isRunningLock.Lock()
+	defer worker.isRunningLock.Unlock()
+
+	if worker.isRunning {
+		return nil
+	}
+
+	if worker.Handler == nil {
+		return fmt.Errorf("a handler is required for job %s", worker.JobName)
+	}
+
+	if !validators.ValidateWorkerPool(worker.Pool, true) || !validators.ValidateWorkerTags(worker.Tags) {
+		return fmt.Errorf("invalid worker pool %q or tags %v for job %s", worker.Pool, worker.Tags, worker.JobName)
+	}
+
+	log.Printf("Starting worker for job %s...", worker.JobName)
+	worker.runsLock.Lock()
+	worker.runs = map[string]*Run{}
+	worker.requeuedActions = map[string]int{}
+	worker.stopping = false
+	worker.runsLock.Unlock()
+
+	// Actions are routed to a queue for each worker pool and set of tags, so
+	// the worker consumes the queues of every subset of its tags
+	fullName := "scheduler.job." + worker.JobName
+	bindings := map[string][]string{
+		fullName + ".status":    {"status"},
+		fullName + ".heartbeat": {"heartbeat"},
+		fullName + ".log":       {"log"},
+	}
+	worker.actionQueues = []string{}
+	for _, routingKey := range workerPools.WorkerRoutingKeys(worker.Pool, worker.Tags) {
+		queue := workerPools.ActionQueue(worker.JobName, routingKey)
+		bindings[queue] = []string{routingKey}
+		worker.actionQueues = append(worker.actionQueues, queue)
+	}
+
+	err := worker.MessageBus.Register(fullName, bindings)
+	if err != nil {
+		return fmt.Errorf("failed to register job %s to message bus: %s", worker.JobName, err)
+	}
+
+	for i, queue := range worker.actionQueues {
+		err = worker.MessageBus.Subscribe(
+			wg,
+			queue,
+			func(body []byte) (error, bool) {
+				return worker.actionMessageReceived(wg, body)
+			},
+		)
+		if err != nil {
+			for _, subscribed := range worker.actionQueues[:i] {
+				worker.MessageBus.Unsubscribe(subscribed)
+			}
+
+			return fmt.Errorf("failed to subscribe to action queue %s for job %s: %s", queue, worker.JobName, err)
+		}
+	}
+
+	if err := worker.register(wg); err != nil {
+		worker.unsubscribeActions()
+		return err
+	}
+
+	worker.isRunning = true
+	log.Printf("Started worker for job %s", worker.JobName)
+	return nil
+}
+
+// Stop stops consuming actions and waits up to ShutdownTimeout for the runs
+// being performed to finish. Runs that are still going are then cancelled with
+// ErrShutdown and no status is sent for them, so the Scheduler restarts them
+// once their heartbeat times out.
+func (worker *Worker) Stop() {
+	worker.isRunningLock.Lock()
+	defer worker.isRunningLock.Unlock()
+
+	if !worker.isRunning {
+		return
+	}
+
+	log.Printf("Stopping worker for job %s...", worker.JobName)
+	worker.unsubscribeActions()
+
+	worker.runsLock.Lock()
+	worker.stopping = true
+	worker.runsLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		worker.runsWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(worker.shutdownTimeout()):
+		log.Printf("Cancelling unfinished runs of job %s", worker.JobName)
+		worker.runsLock.Lock()
+		for _, run := range worker.runs {
+			run.cancel(ErrShutdown)
+		}
+		worker.runsLock.Unlock()
+		<-done
+	}
+
+	worker.unregister()
+	worker.isRunning = false
+	log.Printf("Stopped worker for job %s", worker.JobName)
+}
+
+func (worker *Worker) unsubscribeActions() {
+	for _, queue := range worker.actionQueues {
+		worker.MessageBus.Unsubscribe(queue)
+	}
+}
+
+// register announces the worker to the registry and keeps sending heartbeats
+// with its capacity until it is stopped.
+func (worker *Worker) register(wg *sync.WaitGroup) error {
+	if worker.Id == "" {
+		worker.Id = uuid.NewString()
+	}
+
+	if worker.Hostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to get hostname: %s", err)
+		}
+
+		worker.Hostname = hostname
+	}
+
+	err := worker.MessageBus.Register(
+		messageBus.WorkersExchange,
+		map[string][]string{
+			messageBus.WorkersExchange + ".heartbeat":  {"heartbeat"},
+			messageBus.WorkersExchange + ".unregister": {"unregister"},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register workers to message bus: %s", err)
+	}
+
+	if err := worker.publishWorkerMessage("heartbeat"); err != nil {
+		log.Printf("Failed to register worker %s for job %s: %s", worker.Id, worker.JobName, err)
+	}
+
+	worker.quit = make(chan struct{})
+	wg.Add(1)
+	go worker.sendWorkerHeartbeats(wg, worker.quit)
+	return nil
+}
+
+func (worker *Worker) unregister() {
+	close(worker.quit)
+	if err := worker.publishWorkerMessage("unregister"); err != nil {
+		log.Printf("Failed to unregister worker %s for job %s: %s", worker.Id, worker.JobName, err)
+	}
+}
+
+func (worker *Worker) sendWorkerHeartbeats(wg *sync.WaitGroup, quit <-chan struct{}) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(worker.heartbeatDuration())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			if err := worker.publishWorkerMessage("heartbeat"); err != nil {
+				log.Printf("Failed to publish heartbeat for worker %s: %s", worker.Id, err)
+			}
+		}
+	}
+}
+
+func (worker *Worker) publishWorkerMessage(key string) error {
+	worker.runsLock.Lock()
+	activeRuns := len(worker.runs)
+	worker.runsLock.Unlock()
+
+	body, err := json.Marshal(dtos.WorkerMessage{
+		WorkerId:          worker.Id,
+		Hostname:          worker.Hostname,
+		JobNames:          []string{worker.JobName},
+		Pool:              worker.Pool,
+		Tags:              worker.Tags,
+		Capacity:          worker.maxConcurrentRuns(),
+		ActiveRuns:        activeRuns,
+		Version:           worker.Version,
+		HeartbeatInterval: int(worker.heartbeatDuration().Milliseconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize worker message for worker %s: %s", worker.Id, err)
+	}
+
+	return worker.MessageBus.Publish(messageBus.WorkersExchange, key, body)
+}
+
+func (worker *Worker) actionMessageReceived(wg *sync.WaitGroup, body []byte) (error, bool) {
+	var actionMsg dtos.JobActionMessage
+	if err := json.Unmarshal(body, &actionMsg); err != nil {
+		return fmt.Errorf("failed to parse action message: %s", err), false
+	}
+
+	action := jobActions.JobAction(actionMsg.Action)
+	switch action {
+	case jobActions.Run:
+		return worker.startRun(wg, actionMsg)
+	case jobActions.Cancel:
+		run, found := worker.activeRun(actionMsg.RunId)
+		if !found {
+			return worker.actionNotPerformed(actionMsg)
+		}
+
+		cause := ErrCancelled
+		if actionMsg.Reason != "" {
+			cause = fmt.Errorf("%w: %s", ErrCancelled, actionMsg.Reason)
+		}
+
+		run.cancel(cause)
+	case jobActions.Timeout:
+		run, found := worker.activeRun(actionMsg.RunId)
+		if !found {
+			return worker.actionNotPerformed(actionMsg)
+		}
+
+		if worker.RunTimedOut != nil {
+			worker.RunTimedOut(run)
+		}
+	default:
+		return fmt.Errorf("unsupported action: %s", action), false
+	}
+
+	return nil, false
+}
+
+// actionNotPerformed handles a cancel or timeout action for a run that the
+// worker is not performing. Workers of a job take actions from the same queue,
+// so an action for a run that another worker is performing is requeued for it
+// to receive. Other actions are ignored, such as those for runs that have
+// finished or not yet started.
+func (worker *Worker) actionNotPerformed(actionMsg dtos.JobActionMessage) (error, bool) {
+	if actionMsg.WorkerId == "" || actionMsg.WorkerId == worker.Id {
+		log.Printf("Worker for job %s is not performing run %s, ignoring %s action", worker.JobName, actionMsg.RunId, actionMsg.Action)
+		return nil, false
+	}
+
+	worker.runsLock.Lock()
+	defer worker.runsLock.Unlock()
+
+	key := actionMsg.RunId + "." + actionMsg.Action
+	if worker.requeuedActions[key] >= maxActionRequeues {
+		delete(worker.requeuedActions, key)
+		log.Printf("Worker %s of run %s did not take its %s action, ignoring it", actionMsg.WorkerId, actionMsg.RunId, actionMsg.Action)
+		return nil, false
+	}
+
+	if len(worker.requeuedActions) >= maxRequeuedActions {
+		clear(worker.requeuedActions)
+	}
+
+	worker.requeuedActions[key]++
+	return fmt.Errorf("run %s of job %s is performed by worker %s", actionMsg.RunId, worker.JobName, actionMsg.WorkerId), true
+}
+
+// startRun publishes that a run is running and starts performing it. The action
+// is requeued straight away if the worker is already performing
+// MaxConcurrentRuns runs.
+func (worker *Worker) startRun(wg *sync.WaitGroup, actionMsg dtos.JobActionMessage) (error, bool) {
+	worker.runsLock.Lock()
+
+	if worker.stopping {
+		worker.runsLock.Unlock()
+		return fmt.Errorf("worker for job %s is stopping", worker.JobName), true
+	}
+
+	if _, found := worker.runs[actionMsg.RunId]; found {
+		worker.runsLock.Unlock()
+		log.Printf("Worker for job %s is already performing run %s, ignoring duplicate run action", worker.JobName, actionMsg.RunId)
+		return nil, false
+	}
+
+	if len(worker.runs) >= worker.maxConcurrentRuns() {
+		worker.runsLock.Unlock()
+		return fmt.Errorf("worker for job %s is already performing %d runs", worker.JobName, worker.maxConcurrentRuns()), true
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	run := &Run{
+		Id:         actionMsg.RunId,
+		JobName:    worker.JobName,
+		Attempt:    actionMsg.Attempt,
+		Restart:    actionMsg.Restart,
+		Parameters: actionMsg.Parameters,
+		worker:     worker,
+		cancel:     cancel,
+	}
+	worker.runs[run.Id] = run
+	worker.runsWg.Add(1)
+	worker.runsLock.Unlock()
+
+	status := dtos.JobStatusMessage{
+		Status: string(runStatuses.Running),
+	}
+	if err := worker.publishStatus(run, status); err != nil {
+		worker.finishRun(run)
+		cancel(nil)
+		return err, true
+	}
+
+	wg.Add(1)
+	go worker.perform(ctx, wg, run)
+	return nil, false
+}
+
+func (worker *Worker) perform(ctx context.Context, wg *sync.WaitGroup, run *Run) {
+	defer wg.Done()
+	defer worker.finishRun(run)
+	defer run.cancel(nil)
+
+	stopHeartbeats := make(chan struct{})
+	go worker.sendHeartbeats(run, stopHeartbeats)
+
+	err := worker.handle(ctx, run)
+	close(stopHeartbeats)
+
+	cause := context.Cause(ctx)
+	result, exitCode := run.currentResult()
+	status := dtos.JobStatusMessage{
+		Result:   result,
+		ExitCode: exitCode,
+	}
+	switch {
+	case errors.Is(cause, ErrShutdown):
+		log.Printf("Worker for job %s stopped before run %s finished", worker.JobName, run.Id)
+		return
+	case errors.Is(cause, ErrCancelled):
+		status.Status = string(runStatuses.Cancelled)
+	case err != nil:
+		status.Status = string(runStatuses.Failed)
+		status.Error = err.Error()
+
+		var runErr *RunError
+		if errors.As(err, &runErr) {
+			status.Error = runErr.Err.Error()
+			status.ErrorCode = runErr.Code
+			if runErr.ExitCode != nil {
+				status.ExitCode = runErr.ExitCode
+			}
+		}
+	default:
+		status.Status = string(runStatuses.Completed)
+		progress := 100.0
+		status.Progress = &progress
+	}
+
+	if err := worker.publishStatus(run, status); err != nil {
+		log.Printf("Failed to publish status %s for run %s: %s", status.Status, run.Id, err)
+	}
+}
+
+// handle calls the handler, recovering from panics so that the run fails
+// rather than the worker crashing.
+func (worker *Worker) handle(ctx context.Context, run *Run) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Run %s of job %s panicked: %v\n%s", run.Id, worker.JobName, r, debug.Stack())
+			err = fmt.Errorf("run panicked: %v", r)
+		}
+	}()
+
+	return worker.Handler.Run(ctx, run)
+}
+
+func (worker *Worker) sendHeartbeats(run *Run, stop <-chan struct{}) {
+	ticker := time.NewTicker(worker.heartbeatDuration())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := worker.publishHeartbeat(run); err != nil {
+				log.Printf("Failed to publish heartbeat for run %s: %s", run.Id, err)
+			}
+		}
+	}
+}
+
+func (worker *Worker) publishStatus(run *Run, status dtos.JobStatusMessage) error {
+	status.JobName = worker.JobName
+	status.RunId = run.Id
+	status.WorkerId = worker.Id
+	status.MessageId = uuid.NewString()
+	status.Sequence = run.nextSequence()
+
+	body, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to serialize job status %s for run %s: %s", status.Status, run.Id, err)
+	}
+
+	if err := worker.publish("status", body); err != nil {
+		return fmt.Errorf("failed to publish job status %s for run %s: %s", status.Status, run.Id, err)
+	}
+
+	return nil
+}
+
+func (worker *Worker) publishHeartbeat(run *Run) error {
+	progress, progressMessage := run.currentProgress()
+	body, err := json.Marshal(dtos.JobHeartbeatMessage{
+		JobName:         worker.JobName,
+		RunId:           run.Id,
+		MessageId:       uuid.NewString(),
+		Sequence:        run.nextSequence(),
+		Progress:        progress,
+		ProgressMessage: progressMessage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize job heartbeat for run %s: %s", run.Id, err)
+	}
+
+	return worker.publish("heartbeat", body)
+}
+
+func (worker *Worker) publish(key string, body []byte) error {
+	return worker.MessageBus.Publish("scheduler.job."+worker.JobName, key, body)
+}
+
+func (worker *Worker) activeRun(runId string) (*Run, bool) {
+	worker.runsLock.Lock()
+	defer worker.runsLock.Unlock()
+	run, found := worker.runs[runId]
+	return run, found
+}
+
+func (worker *Worker) finishRun(run *Run) {
+	worker.runsLock.Lock()
+	defer worker.runsLock.Unlock()
+
+	if worker.runs[run.Id] == run {
+		delete(worker.runs, run.Id)
+		worker.runsWg.Done()
+	}
+}
+
+func (worker *Worker) heartbeatDuration() time.Duration {
+	if worker.HeartbeatDuration <= 0 {
+		return defaultHeartbeatDuration
+	}
+
+	return worker.HeartbeatDuration
+}
+
+func (worker *Worker) maxConcurrentRuns() int {
+	if worker.MaxConcurrentRuns <= 0 {
+		return defaultMaxConcurrentRuns
+	}
+
+	return worker.MaxConcurrentRuns
+}
+
+func (worker *Worker) shutdownTimeout() time.Duration {
+	if worker.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+
+	return worker.ShutdownTimeout
+}
@@ -0,0 +1,72 @@
+package workerPools
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultPool is the pool of jobs and workers that do not name one.
+const DefaultPool = "default"
+
+// Workers subscribe to a queue for every combination of their tags, so the
+// number of tags is bounded.
+const MaxTags = 5
+
+const actionKey = "action"
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidName returns whether a pool or tag name can be used in a routing key,
+// which cannot contain dots or the wildcards of topic exchanges.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// RoutingKey returns the key run actions of a job are published with for its
+// worker pool and tags. Jobs in the default pool without tags use the action
+// key, so they keep working with workers that do not know about pools.
+func RoutingKey(pool string, tags []string) string {
+	tags = normalizeTags(tags)
+	if (pool == "" || pool == DefaultPool) && len(tags) == 0 {
+		return actionKey
+	}
+
+	if pool == "" {
+		pool = DefaultPool
+	}
+
+	return strings.Join(append([]string{actionKey, pool}, tags...), ".")
+}
+
+// WorkerRoutingKeys returns the keys of the actions a worker in a pool with
+// the given tags can perform, which are those of jobs in the same pool that
+// require any subset of the tags.
+func WorkerRoutingKeys(pool string, tags []string) []string {
+	tags = normalizeTags(tags)
+	keys := []string{}
+	for mask := 0; mask < 1<<len(tags); mask++ {
+		subset := []string{}
+		for i, tag := range tags {
+			if mask&(1<<i) != 0 {
+				subset = append(subset, tag)
+			}
+		}
+
+		keys = append(keys, RoutingKey(pool, subset))
+	}
+
+	return keys
+}
+
+// ActionQueue returns the queue of a job that actions published with a routing
+// key are delivered to.
+func ActionQueue(jobName string, routingKey string) string {
+	return "scheduler.job." + jobName + "." + routingKey
+}
+
+func normalizeTags(tags []string) []string {
+	tags = slices.Clone(tags)
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
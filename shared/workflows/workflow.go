@@ -0,0 +1,171 @@
+package workflows
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jacobmcgowan/simple-scheduler/shared/dtos"
+	"github.com/jacobmcgowan/simple-scheduler/shared/nodeStatuses"
+	"github.com/jacobmcgowan/simple-scheduler/shared/runStatuses"
+)
+
+// ValidateDependencies checks that the upstream jobs of a job exist and that
+// depending on them would not create a cycle.
+func ValidateDependencies(jobName string, dependsOn []string, jobs []dtos.Job) error {
+	graph := map[string][]string{}
+	for _, job := range jobs {
+		graph[job.Name] = job.DependsOn
+	}
+
+	for _, upstream := range dependsOn {
+		if upstream == jobName {
+			return fmt.Errorf("job %s cannot depend on itself", jobName)
+		}
+
+		if _, found := graph[upstream]; !found {
+			return fmt.Errorf("upstream job %s does not exist", upstream)
+		}
+	}
+
+	graph[jobName] = dependsOn
+	visited := map[string]bool{}
+	stack := slices.Clone(dependsOn)
+	for len(stack) > 0 {
+		name := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if name == jobName {
+			return fmt.Errorf("depending on %v would create a cycle", dependsOn)
+		}
+
+		if visited[name] {
+			continue
+		}
+
+		visited[name] = true
+		stack = append(stack, graph[name]...)
+	}
+
+	return nil
+}
+
+// Dependents returns the jobs that directly depend on the given job.
+func Dependents(jobName string, jobs []dtos.Job) []dtos.Job {
+	dependents := []dtos.Job{}
+	for _, job := range jobs {
+		if slices.Contains(job.DependsOn, jobName) {
+			dependents = append(dependents, job)
+		}
+	}
+
+	return dependents
+}
+
+// Component returns the jobs connected to the given job by dependencies in
+// either direction, including the job itself, ordered so that each job comes
+// after its upstream jobs.
+func Component(jobName string, jobs []dtos.Job) []dtos.Job {
+	jobsByName := map[string]dtos.Job{}
+	for _, job := range jobs {
+		jobsByName[job.Name] = job
+	}
+
+	if _, found := jobsByName[jobName]; !found {
+		return []dtos.Job{}
+	}
+
+	connected := map[string]bool{}
+	stack := []string{jobName}
+	for len(stack) > 0 {
+		name := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if connected[name] {
+			continue
+		}
+
+		connected[name] = true
+		stack = append(stack, jobsByName[name].DependsOn...)
+		for _, dependent := range Dependents(name, jobs) {
+			stack = append(stack, dependent.Name)
+		}
+	}
+
+	ordered := []dtos.Job{}
+	added := map[string]bool{}
+	var add func(name string)
+	add = func(name string) {
+		if added[name] {
+			return
+		}
+
+		added[name] = true
+		job := jobsByName[name]
+		for _, upstream := range job.DependsOn {
+			add(upstream)
+		}
+
+		ordered = append(ordered, job)
+	}
+
+	for _, job := range jobs {
+		if connected[job.Name] {
+			add(job.Name)
+		}
+	}
+
+	return ordered
+}
+
+// Nodes returns the state of each job in a workflow for a period. The jobs
+// must be ordered as returned by Component and the runs should be those for
+// the period. The latest attempt is used when a job has several runs.
+func Nodes(jobs []dtos.Job, runs []dtos.Run) []dtos.WorkflowNode {
+	latestRuns := map[string]dtos.Run{}
+	for _, run := range runs {
+		latest, found := latestRuns[run.JobName]
+		if !found || run.Attempt > latest.Attempt {
+			latestRuns[run.JobName] = run
+		}
+	}
+
+	statuses := map[string]string{}
+	nodes := []dtos.WorkflowNode{}
+	for _, job := range jobs {
+		node := dtos.WorkflowNode{
+			JobName:   job.Name,
+			DependsOn: job.DependsOn,
+			Status:    string(nodeStatuses.Waiting),
+		}
+
+		if run, found := latestRuns[job.Name]; found {
+			node.Status = string(run.Status)
+			node.RunId = run.Id
+		} else {
+			for _, upstream := range job.DependsOn {
+				if isBlocking(statuses[upstream]) {
+					node.Status = string(nodeStatuses.Blocked)
+					break
+				}
+			}
+		}
+
+		statuses[job.Name] = node.Status
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
+func isBlocking(status string) bool {
+	switch status {
+	case string(nodeStatuses.Blocked),
+		string(runStatuses.Cancelled),
+		string(runStatuses.Cancelling),
+		string(runStatuses.Failed),
+		string(runStatuses.Skipped):
+		return true
+	default:
+		return false
+	}
+}